@@ -0,0 +1,449 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// citation holds the bibliographic details of a scholarly item in a form
+// that can be written as CSL-JSON or BibTeX.
+type citation struct {
+	key            string
+	kind           citationKind
+	title          string
+	authors        []cslName
+	editors        []cslName
+	date           []int
+	containerTitle string
+	volume         string
+	issue          string
+	pages          string
+	publisher      string
+	doi            string
+	isbn           string
+	issn           string
+	url            string
+}
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Editor         []cslName `json:"editor,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Volume         string    `json:"volume,omitempty"`
+	Issue          string    `json:"issue,omitempty"`
+	Page           string    `json:"page,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	DOI            string    `json:"DOI,omitempty"`
+	ISBN           string    `json:"ISBN,omitempty"`
+	ISSN           string    `json:"ISSN,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+}
+
+// citationKind is a schema.org type that can be cited along with its CSL and
+// BibTeX equivalents.
+type citationKind struct {
+	schema string
+	csl    string
+	bibtex string
+}
+
+// citationKinds are the schema.org types that can be cited, most specific
+// first.
+var citationKinds = []citationKind{
+	{"ScholarlyArticle", "article-journal", "article"},
+	{"Chapter", "chapter", "incollection"},
+	{"Book", "book", "book"},
+	{"Periodical", "periodical", "misc"},
+}
+
+// CSLJSON converts the ScholarlyArticle, Book, Chapter and Periodical items in
+// the set into a CSL-JSON array of citations. Authors and editors are taken
+// from Person or Organization items, and the container title, volume, issue,
+// ISSN and ISBN are gathered from the chain of isPartOf items.
+func (m *Microdata) CSLJSON() ([]byte, error) {
+	citations, err := m.citations()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]cslItem, 0, len(citations))
+	for _, c := range citations {
+		ci := cslItem{
+			ID:             c.key,
+			Type:           c.kind.csl,
+			Title:          c.title,
+			Author:         c.authors,
+			Editor:         c.editors,
+			ContainerTitle: c.containerTitle,
+			Volume:         c.volume,
+			Issue:          c.issue,
+			Page:           c.pages,
+			Publisher:      c.publisher,
+			DOI:            c.doi,
+			ISBN:           c.isbn,
+			ISSN:           c.issn,
+			URL:            c.url,
+		}
+		if len(c.date) > 0 {
+			ci.Issued = &cslDate{DateParts: [][]int{c.date}}
+		}
+		items = append(items, ci)
+	}
+
+	b, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// BibTeX converts the ScholarlyArticle, Book, Chapter and Periodical items in
+// the set into BibTeX entries. See CSLJSON for how the items are interpreted.
+func (m *Microdata) BibTeX() ([]byte, error) {
+	citations, err := m.citations()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for idx, c := range citations {
+		if idx > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "@%s{%s,\n", c.kind.bibtex, c.key)
+
+		writeBibTeXField(&buf, "author", bibTeXNames(c.authors))
+		writeBibTeXField(&buf, "editor", bibTeXNames(c.editors))
+		writeBibTeXField(&buf, "title", bibTeXEscape(c.title))
+		switch c.kind.schema {
+		case "ScholarlyArticle":
+			writeBibTeXField(&buf, "journal", bibTeXEscape(c.containerTitle))
+		case "Chapter":
+			writeBibTeXField(&buf, "booktitle", bibTeXEscape(c.containerTitle))
+		}
+		if len(c.date) > 0 {
+			writeBibTeXField(&buf, "year", strconv.Itoa(c.date[0]))
+		}
+		if len(c.date) > 1 {
+			writeBibTeXField(&buf, "month", strconv.Itoa(c.date[1]))
+		}
+		writeBibTeXField(&buf, "volume", bibTeXEscape(c.volume))
+		writeBibTeXField(&buf, "number", bibTeXEscape(c.issue))
+		writeBibTeXField(&buf, "pages", bibTeXEscape(bibTeXPages(c.pages)))
+		writeBibTeXField(&buf, "publisher", bibTeXEscape(c.publisher))
+		writeBibTeXField(&buf, "doi", bibTeXEscape(c.doi))
+		writeBibTeXField(&buf, "isbn", bibTeXEscape(c.isbn))
+		writeBibTeXField(&buf, "issn", bibTeXEscape(c.issn))
+		writeBibTeXField(&buf, "url", c.url)
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// citations collects the citable items of the set. Items reached through
+// isPartOf describe the container of another item and are not cited on their
+// own.
+func (m *Microdata) citations() ([]*citation, error) {
+	citations := make([]*citation, 0)
+	keys := make(map[string]int)
+
+	err := m.Walk(func(v Visit) error {
+		item, ok := v.Value.(*Item)
+		if !ok {
+			return nil
+		}
+		if v.Property == "isPartOf" {
+			return ErrSkipItem
+		}
+		if v.Shared {
+			return nil
+		}
+
+		for _, kind := range citationKinds {
+			if isSchemaType(item, kind.schema) {
+				c := newCitation(item, kind)
+				c.key = uniqueKey(c.key, keys)
+				citations = append(citations, c)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return citations, nil
+}
+
+func newCitation(item *Item, kind citationKind) *citation {
+	c := &citation{
+		kind:      kind,
+		title:     cleanText(stringValue(item, "headline", "name")),
+		authors:   citationNames(item, "author", "creator"),
+		editors:   citationNames(item, "editor"),
+		date:      dateParts(stringValue(item, "datePublished", "dateCreated")),
+		publisher: cleanText(textValue(firstValue(item, "publisher"))),
+		isbn:      cleanText(stringValue(item, "isbn")),
+		issn:      cleanText(stringValue(item, "issn")),
+		doi:       itemDOI(item),
+		url:       strings.TrimSpace(stringValue(item, "url")),
+		pages:     cleanText(stringValue(item, "pagination")),
+	}
+
+	if c.pages == "" {
+		start, end := cleanText(stringValue(item, "pageStart")), cleanText(stringValue(item, "pageEnd"))
+		c.pages = start
+		if start != "" && end != "" {
+			c.pages = start + "-" + end
+		}
+	}
+
+	// Gather container details from the isPartOf chain, stopping at cycles.
+	seen := map[*Item]bool{item: true}
+	for parent := itemValue(item, "isPartOf"); parent != nil && !seen[parent]; parent = itemValue(parent, "isPartOf") {
+		seen[parent] = true
+		switch {
+		case isSchemaType(parent, "PublicationIssue"):
+			setIfEmpty(&c.issue, cleanText(stringValue(parent, "issueNumber")))
+		case isSchemaType(parent, "PublicationVolume"):
+			setIfEmpty(&c.volume, cleanText(stringValue(parent, "volumeNumber")))
+		default:
+			setIfEmpty(&c.containerTitle, cleanText(stringValue(parent, "name", "headline")))
+			setIfEmpty(&c.issn, cleanText(stringValue(parent, "issn")))
+			if kind.schema == "Chapter" {
+				setIfEmpty(&c.isbn, cleanText(stringValue(parent, "isbn")))
+				if len(c.editors) == 0 {
+					c.editors = citationNames(parent, "editor")
+				}
+			}
+		}
+		setIfEmpty(&c.publisher, cleanText(textValue(firstValue(parent, "publisher"))))
+		if len(c.date) == 0 {
+			c.date = dateParts(stringValue(parent, "datePublished"))
+		}
+	}
+
+	// The key is always built rather than taken from the itemid, which may
+	// contain characters that are not allowed in a BibTeX key.
+	c.key = citationKey(c)
+	return c
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// citationNames converts the values of the named properties into names,
+// splitting Person items into family and given names where possible.
+func citationNames(item *Item, properties ...string) []cslName {
+	names := make([]cslName, 0)
+	for _, property := range properties {
+		for _, v := range item.Properties[property] {
+			switch tv := v.(type) {
+			case string:
+				if s := cleanText(tv); s != "" {
+					names = append(names, cslName{Literal: s})
+				}
+			case *Item:
+				family, given := cleanText(stringValue(tv, "familyName")), cleanText(stringValue(tv, "givenName"))
+				if family != "" {
+					names = append(names, cslName{Family: family, Given: given})
+				} else if name := cleanText(stringValue(tv, "name")); name != "" {
+					names = append(names, cslName{Literal: name})
+				}
+			}
+		}
+		if len(names) > 0 {
+			break
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
+
+// itemDOI finds a DOI in the item's identifier or sameAs properties, which may
+// hold a doi: URI, a doi.org URL, a bare DOI or a PropertyValue item.
+func itemDOI(item *Item) string {
+	for _, name := range []string{"identifier", "sameAs", "url"} {
+		for _, v := range item.Properties[name] {
+			switch tv := v.(type) {
+			case string:
+				if doi := parseDOI(tv, name == "identifier"); doi != "" {
+					return doi
+				}
+			case *Item:
+				if strings.EqualFold(cleanText(stringValue(tv, "propertyID")), "doi") {
+					if doi := parseDOI(stringValue(tv, "value"), true); doi != "" {
+						return doi
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func parseDOI(s string, allowBare bool) string {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		if len(s) > len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			return s[len(prefix):]
+		}
+	}
+	if allowBare && strings.HasPrefix(s, "10.") && strings.Contains(s, "/") {
+		return s
+	}
+	return ""
+}
+
+// dateParts converts a date such as 2020, 2020-05 or 2020-05-17, optionally
+// followed by a time, into CSL date parts.
+func dateParts(s string) []int {
+	s = strings.TrimSpace(s)
+	if len(s) > 10 {
+		s = s[:10]
+	}
+	parts := make([]int, 0, 3)
+	for _, field := range strings.Split(s, "-") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return parts
+}
+
+// citationKey builds a key from the first author's family name, the year and
+// the first word of the title, such as doe2020widgets.
+func citationKey(c *citation) string {
+	var b strings.Builder
+	if len(c.authors) > 0 {
+		name := c.authors[0].Family
+		if name == "" {
+			name = c.authors[0].Literal
+		}
+		b.WriteString(keyWord(name))
+	}
+	if len(c.date) > 0 {
+		b.WriteString(strconv.Itoa(c.date[0]))
+	}
+	for _, word := range strings.Fields(c.title) {
+		if w := keyWord(word); len(w) > 3 {
+			b.WriteString(w)
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "item"
+	}
+	return b.String()
+}
+
+// keyWord keeps the letters and digits of the first word of s, lower cased.
+func keyWord(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(fields[0]) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// uniqueKey appends a letter to key if it has already been used.
+func uniqueKey(key string, used map[string]int) string {
+	n := used[key]
+	used[key] = n + 1
+	if n == 0 {
+		return key
+	}
+	suffix := ""
+	for ; n > 0; n = (n - 1) / 26 {
+		suffix = string(rune('a'+(n-1)%26)) + suffix
+	}
+	return key + suffix
+}
+
+func writeBibTeXField(buf *bytes.Buffer, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(buf, "  %s = {%s},\n", name, value)
+}
+
+func bibTeXNames(names []cslName) string {
+	formatted := make([]string, 0, len(names))
+	for _, n := range names {
+		switch {
+		case n.Literal != "":
+			// Braces stop BibTeX from splitting the name into parts.
+			formatted = append(formatted, "{"+bibTeXEscape(n.Literal)+"}")
+		case n.Given != "":
+			formatted = append(formatted, bibTeXEscape(n.Family)+", "+bibTeXEscape(n.Given))
+		default:
+			formatted = append(formatted, bibTeXEscape(n.Family))
+		}
+	}
+	return strings.Join(formatted, " and ")
+}
+
+// bibTeXPages writes page ranges with the double hyphen BibTeX expects.
+func bibTeXPages(s string) string {
+	s = strings.ReplaceAll(s, "–", "-")
+	s = strings.ReplaceAll(s, "--", "-")
+	return strings.ReplaceAll(s, "-", "--")
+}
+
+var bibTeXReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// bibTeXEscape escapes the characters that have special meaning in BibTeX
+// and LaTeX.
+func bibTeXEscape(s string) string {
+	return bibTeXReplacer.Replace(s)
+}
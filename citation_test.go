@@ -0,0 +1,132 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const scholarlyArticleHTML = `<div itemscope itemtype="http://schema.org/ScholarlyArticle">
+  <h1 itemprop="headline">Widgets & Their Uses</h1>
+  <span itemprop="author" itemscope itemtype="http://schema.org/Person">
+    <span itemprop="givenName">Jane</span> <span itemprop="familyName">Doe</span>
+  </span>
+  <span itemprop="author" itemscope itemtype="http://schema.org/Organization">
+    <span itemprop="name">Widget Consortium</span>
+  </span>
+  <meta itemprop="datePublished" content="2020-05-17">
+  <meta itemprop="pagination" content="123-145">
+  <link itemprop="sameAs" href="https://doi.org/10.1234/widgets.5678">
+  <div itemprop="isPartOf" itemscope itemtype="http://schema.org/PublicationIssue">
+    <meta itemprop="issueNumber" content="4">
+    <div itemprop="isPartOf" itemscope itemtype="http://schema.org/PublicationVolume">
+      <meta itemprop="volumeNumber" content="12">
+      <div itemprop="isPartOf" itemscope itemtype="http://schema.org/Periodical">
+        <span itemprop="name">Journal of Widgets</span>
+        <meta itemprop="issn" content="1234-5678">
+      </div>
+    </div>
+  </div>
+</div>`
+
+func TestCSLJSON(t *testing.T) {
+	b, err := ParseData(scholarlyArticleHTML, t).CSLJSON()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []byte(`[{"id":"doe2020widgets","type":"article-journal","title":"Widgets \u0026 Their Uses",` +
+		`"author":[{"family":"Doe","given":"Jane"},{"literal":"Widget Consortium"}],` +
+		`"issued":{"date-parts":[[2020,5,17]]},"container-title":"Journal of Widgets","volume":"12","issue":"4",` +
+		`"page":"123-145","DOI":"10.1234/widgets.5678","ISSN":"1234-5678"}]`)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestBibTeX(t *testing.T) {
+	b, err := ParseData(scholarlyArticleHTML, t).BibTeX()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `@article{doe2020widgets,
+  author = {Doe, Jane and {Widget Consortium}},
+  title = {Widgets \& Their Uses},
+  journal = {Journal of Widgets},
+  year = {2020},
+  month = {5},
+  volume = {12},
+  number = {4},
+  pages = {123--145},
+  doi = {10.1234/widgets.5678},
+  issn = {1234-5678},
+}
+`
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
+
+func TestBibTeXChapterAndKeys(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Chapter">
+	  <span itemprop="name">Gears</span>
+	  <span itemprop="author">Smith</span>
+	  <meta itemprop="datePublished" content="2019">
+	  <meta itemprop="pageStart" content="1"><meta itemprop="pageEnd" content="20">
+	  <div itemprop="isPartOf" itemscope itemtype="http://schema.org/Book">
+	    <span itemprop="name">Machines</span>
+	    <meta itemprop="isbn" content="978-3-16-148410-0">
+	    <span itemprop="publisher">Acme Press</span>
+	  </div>
+	</div>
+	<div itemscope itemtype="http://schema.org/Book">
+	  <span itemprop="name">Gears</span>
+	  <span itemprop="author">Smith</span>
+	  <meta itemprop="datePublished" content="2019">
+	</div>`
+
+	b, err := ParseData(html, t).BibTeX()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `@incollection{smith2019gears,
+  author = {{Smith}},
+  title = {Gears},
+  booktitle = {Machines},
+  year = {2019},
+  pages = {1--20},
+  publisher = {Acme Press},
+  isbn = {978-3-16-148410-0},
+}
+
+@book{smith2019gearsa,
+  author = {{Smith}},
+  title = {Gears},
+  year = {2019},
+}
+`
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
+
+func TestBibTeXKeyIgnoresItemID(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Book" itemid="http://example.com/b?x=1,2">
+	  <span itemprop="name">Gears</span>
+	</div>`
+
+	b, err := ParseData(html, t).BibTeX()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !strings.HasPrefix(string(b), "@book{gears,\n") {
+		t.Errorf("Expecting key built from the title but got %s", b)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Clone returns a deep copy of the item. Nested items that are shared between
+// several properties, or that refer back to one of their ancestors, are
+// copied once so the copy has the same shape as the original.
+func (i *Item) Clone() *Item {
+	return cloneItem(i, make(map[*Item]*Item))
+}
+
+func cloneItem(item *Item, copies map[*Item]*Item) *Item {
+	if c, exists := copies[item]; exists {
+		return c
+	}
+
+	c := NewItem()
+	copies[item] = c
+	c.ID = item.ID
+	c.Types = append(c.Types, item.Types...)
+
+	for _, name := range item.PropertyNames() {
+		for _, v := range item.Properties[name] {
+			if child, ok := v.(*Item); ok {
+				c.addValue(name, cloneItem(child, copies))
+				continue
+			}
+			c.addValue(name, v)
+		}
+	}
+	return c
+}
+
+// Equal reports whether the item and other have the same ID, types and
+// property values. Types are compared as an unordered set, as they are in an
+// itemtype attribute, while the values of each property are compared in order.
+// Nested items are compared by content rather than identity.
+func (i *Item) Equal(other *Item) bool {
+	return equalItems(i, other, make(map[[2]*Item]bool))
+}
+
+func equalItems(a, b *Item, visiting map[[2]*Item]bool) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+
+	// A pair already being compared further up is assumed equal, which
+	// allows cyclic items to be compared.
+	key := [2]*Item{a, b}
+	if visiting[key] {
+		return true
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	if a.ID != b.ID || !sameTypeSet(a.Types, b.Types) || len(a.Properties) != len(b.Properties) {
+		return false
+	}
+
+	for name, av := range a.Properties {
+		bv, exists := b.Properties[name]
+		if !exists || len(av) != len(bv) {
+			return false
+		}
+		for idx := range av {
+			switch tv := av[idx].(type) {
+			case string:
+				if s, ok := bv[idx].(string); !ok || s != tv {
+					return false
+				}
+			case *Item:
+				if child, ok := bv[idx].(*Item); !ok || !equalItems(tv, child, visiting) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Hash returns a fingerprint of the item's ID, types and property values as a
+// hex encoded SHA-256 digest. Items that are Equal have the same hash. The
+// hash does not depend on the order of types or property names, or on how
+// nested items are shared, so it is stable across parses of the same content.
+func (i *Item) Hash() string {
+	h := sha256.New()
+	writeCanonical(h, i, make(map[*Item]int), 0)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeCanonical writes an unambiguous serialization of the item. Strings are
+// length prefixed and a nested item that is one of its own ancestors is
+// written as a reference to the depth of that ancestor.
+func writeCanonical(w io.Writer, item *Item, ancestors map[*Item]int, depth int) {
+	if d, exists := ancestors[item]; exists {
+		fmt.Fprintf(w, "^%d;", d)
+		return
+	}
+	ancestors[item] = depth
+	defer delete(ancestors, item)
+
+	fmt.Fprintf(w, "{id%d:%s", len(item.ID), item.ID)
+
+	types := uniqueSorted(item.Types)
+	fmt.Fprintf(w, "types%d", len(types))
+	for _, t := range types {
+		fmt.Fprintf(w, ":%d:%s", len(t), t)
+	}
+
+	names := make([]string, 0, len(item.Properties))
+	for name := range item.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values := item.Properties[name]
+		fmt.Fprintf(w, "prop%d:%s[%d", len(name), name, len(values))
+		for _, v := range values {
+			switch tv := v.(type) {
+			case string:
+				fmt.Fprintf(w, "s%d:%s", len(tv), tv)
+			case *Item:
+				writeCanonical(w, tv, ancestors, depth+1)
+			}
+		}
+		fmt.Fprint(w, "]")
+	}
+	fmt.Fprint(w, "}")
+}
+
+func sameTypeSet(a, b []string) bool {
+	ua, ub := uniqueSorted(a), uniqueSorted(b)
+	if len(ua) != len(ub) {
+		return false
+	}
+	for i := range ua {
+		if ua[i] != ub[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func uniqueSorted(list []string) []string {
+	sorted := append([]string(nil), list...)
+	sort.Strings(sorted)
+
+	unique := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
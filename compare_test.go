@@ -0,0 +1,96 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"testing"
+)
+
+func TestCloneIsDeep(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Person">
+			 <p>Name: <span itemprop="name">Amanda</span></p>
+			 <p>Band: <span itemprop="band" itemscope> <span itemprop="name">Jazz Band</span></span></p>
+			</div>`
+
+	item := ParseOneItem(html, t)
+	clone := item.Clone()
+
+	if !item.Equal(clone) {
+		t.Fatalf("Expecting clone to equal original")
+	}
+
+	clone.Properties["band"][0].(*Item).AddString("size", "12")
+	if _, exists := item.Properties["band"][0].(*Item).Properties["size"]; exists {
+		t.Errorf("Expecting nested item of original to be unchanged")
+	}
+	if item.Equal(clone) {
+		t.Errorf("Expecting modified clone not to equal original")
+	}
+}
+
+func TestCloneCyclic(t *testing.T) {
+	org := NewItem()
+	person := NewItem()
+	person.AddItem("worksFor", org)
+	person.AddItem("affiliation", org)
+	org.AddItem("employee", person)
+
+	clone := person.Clone()
+
+	worksFor := clone.Properties["worksFor"][0].(*Item)
+	if worksFor != clone.Properties["affiliation"][0].(*Item) {
+		t.Errorf("Expecting shared item to remain shared in clone")
+	}
+	if worksFor.Properties["employee"][0].(*Item) != clone {
+		t.Errorf("Expecting cycle to be preserved in clone")
+	}
+	if !person.Equal(clone) {
+		t.Errorf("Expecting cyclic clone to equal original")
+	}
+}
+
+func TestEqualIgnoresTypeOrder(t *testing.T) {
+	a := NewItem()
+	a.AddType("http://schema.org/Product")
+	a.AddType("http://schema.org/IndividualProduct")
+	a.AddString("name", "Widget")
+	a.AddString("color", "Red")
+
+	b := NewItem()
+	b.AddType("http://schema.org/IndividualProduct")
+	b.AddType("http://schema.org/Product")
+	b.AddString("color", "Red")
+	b.AddString("name", "Widget")
+
+	if !a.Equal(b) {
+		t.Errorf("Expecting items to be equal")
+	}
+	if a.Hash() != b.Hash() {
+		t.Errorf("Expecting equal items to have the same hash")
+	}
+
+	b.AddString("color", "Blue")
+	if a.Equal(b) {
+		t.Errorf("Expecting items not to be equal")
+	}
+	if a.Hash() == b.Hash() {
+		t.Errorf("Expecting different items to have different hashes")
+	}
+}
+
+func TestHashIsUnambiguous(t *testing.T) {
+	a := NewItem()
+	a.AddString("name", "ab")
+	a.AddString("name", "c")
+
+	b := NewItem()
+	b.AddString("name", "a")
+	b.AddString("name", "bc")
+
+	if a.Hash() == b.Hash() {
+		t.Errorf("Expecting different value boundaries to give different hashes")
+	}
+}
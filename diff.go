@@ -0,0 +1,309 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChangeKind identifies the kind of difference reported by Diff.
+type ChangeKind string
+
+// Kinds of change reported by Diff.
+const (
+	ItemAdded    ChangeKind = "item-added"
+	ItemRemoved  ChangeKind = "item-removed"
+	TypeChanged  ChangeKind = "type-changed"
+	IDChanged    ChangeKind = "id-changed"
+	ValueAdded   ChangeKind = "value-added"
+	ValueRemoved ChangeKind = "value-removed"
+	ValueChanged ChangeKind = "value-changed"
+)
+
+// Change is a single difference between two microdata sets. Path locates the
+// changed value: positions are taken from the newer set, except for removed
+// items and values whose positions are taken from the older set. Old and New
+// hold the values before and after the change; they are strings, items or,
+// for type changes, lists of types.
+type Change struct {
+	Kind ChangeKind  `json:"kind"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Delta is the list of differences between two microdata sets.
+type Delta struct {
+	Changes []Change `json:"changes"`
+}
+
+// diffKeyProperties are the properties used, in order of preference, to align
+// items that have no ID.
+var diffKeyProperties = []string{"url", "sku", "gtin", "gtin13", "identifier", "name", "headline"}
+
+// Diff compares two microdata sets and reports how b differs from a. Items are
+// aligned by ID first, then by type and the value of a key property such as
+// url or name, and finally by type alone. Within aligned items each
+// property's values are compared, descending into nested items that are
+// aligned in the same way. A nil set is treated as empty, so every item of the
+// other set is reported as added or removed.
+func Diff(a, b *Microdata) *Delta {
+	if a == nil {
+		a = NewMicrodata()
+	}
+	if b == nil {
+		b = NewMicrodata()
+	}
+	d := &differ{delta: &Delta{Changes: make([]Change, 0)}, seen: make(map[[2]*Item]bool)}
+	d.diffTopLevel(a.Items, b.Items)
+	return d.delta
+}
+
+// Empty reports whether there are no differences.
+func (d *Delta) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// JSON converts the list of differences to JSON
+func (d *Delta) JSON() ([]byte, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Text formats the list of differences with one change per line. Lines are
+// prefixed with + for additions, - for removals and ~ for changes.
+func (d *Delta) Text() string {
+	var b strings.Builder
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case ItemAdded, ValueAdded:
+			fmt.Fprintf(&b, "+ %s: %s\n", c.Path, describeValue(c.New))
+		case ItemRemoved, ValueRemoved:
+			fmt.Fprintf(&b, "- %s: %s\n", c.Path, describeValue(c.Old))
+		case TypeChanged:
+			fmt.Fprintf(&b, "~ %s type: %s -> %s\n", c.Path, describeValue(c.Old), describeValue(c.New))
+		case IDChanged:
+			fmt.Fprintf(&b, "~ %s id: %s -> %s\n", c.Path, describeValue(c.Old), describeValue(c.New))
+		default:
+			fmt.Fprintf(&b, "~ %s: %s -> %s\n", c.Path, describeValue(c.Old), describeValue(c.New))
+		}
+	}
+	return b.String()
+}
+
+func describeValue(v interface{}) string {
+	switch tv := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", tv)
+	case []string:
+		return "[" + strings.Join(tv, " ") + "]"
+	case *Item:
+		desc := "item"
+		if len(tv.Types) > 0 {
+			desc += " " + strings.Join(tv.Types, " ")
+		}
+		if tv.ID != "" {
+			desc += " <" + tv.ID + ">"
+		}
+		return desc
+	}
+	return fmt.Sprint(v)
+}
+
+type differ struct {
+	delta *Delta
+	seen  map[[2]*Item]bool
+}
+
+func (d *differ) add(kind ChangeKind, path Path, before, after interface{}) {
+	d.delta.Changes = append(d.delta.Changes, Change{Kind: kind, Path: path.String(), Old: before, New: after})
+}
+
+// diffTopLevel compares the top-level items of two sets.
+func (d *differ) diffTopLevel(a, b []*Item) {
+	pairs, removed := alignItems(a, b)
+
+	for bi, ai := range pairs {
+		p := Path{{Index: bi}}
+		if ai < 0 {
+			d.add(ItemAdded, p, nil, b[bi])
+			continue
+		}
+		d.diffItems(p, a[ai], b[bi])
+	}
+
+	for _, ai := range removed {
+		d.add(ItemRemoved, Path{{Index: ai}}, a[ai], nil)
+	}
+}
+
+func (d *differ) diffItems(path Path, a, b *Item) {
+	key := [2]*Item{a, b}
+	if d.seen[key] {
+		return
+	}
+	d.seen[key] = true
+
+	if a.ID != b.ID {
+		d.add(IDChanged, path, a.ID, b.ID)
+	}
+	if !sameTypeSet(a.Types, b.Types) {
+		d.add(TypeChanged, path, a.Types, b.Types)
+	}
+
+	names := b.PropertyNames()
+	for _, name := range a.PropertyNames() {
+		if _, exists := b.Properties[name]; !exists {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		d.diffValues(path, name, a.Properties[name], b.Properties[name])
+	}
+}
+
+func (d *differ) diffValues(path Path, property string, a, b valueList) {
+	aStrings, aItems, aStringIdx, aItemIdx := splitValues(a)
+	bStrings, bItems, bStringIdx, bItemIdx := splitValues(b)
+
+	// Values present in both lists are unchanged wherever they appear.
+	unmatchedA := make([]int, 0)
+	used := make([]bool, len(bStrings))
+	for ai, av := range aStrings {
+		found := false
+		for bi, bv := range bStrings {
+			if !used[bi] && av == bv {
+				used[bi] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatchedA = append(unmatchedA, ai)
+		}
+	}
+	unmatchedB := make([]int, 0)
+	for bi := range bStrings {
+		if !used[bi] {
+			unmatchedB = append(unmatchedB, bi)
+		}
+	}
+
+	// Remaining values are paired up in order as changes.
+	n := 0
+	for ; n < len(unmatchedA) && n < len(unmatchedB); n++ {
+		ai, bi := unmatchedA[n], unmatchedB[n]
+		d.add(ValueChanged, appendPath(path, property, bStringIdx[bi]), aStrings[ai], bStrings[bi])
+	}
+	for _, bi := range unmatchedB[n:] {
+		d.add(ValueAdded, appendPath(path, property, bStringIdx[bi]), nil, bStrings[bi])
+	}
+	for _, ai := range unmatchedA[n:] {
+		d.add(ValueRemoved, appendPath(path, property, aStringIdx[ai]), aStrings[ai], nil)
+	}
+
+	pairs, removed := alignItems(aItems, bItems)
+	for bi, ai := range pairs {
+		p := appendPath(path, property, bItemIdx[bi])
+		if ai < 0 {
+			d.add(ValueAdded, p, nil, bItems[bi])
+			continue
+		}
+		d.diffItems(p, aItems[ai], bItems[bi])
+	}
+	for _, ai := range removed {
+		d.add(ValueRemoved, appendPath(path, property, aItemIdx[ai]), aItems[ai], nil)
+	}
+}
+
+// splitValues separates a list of values into strings and items, recording
+// the position of each in the original list.
+func splitValues(values valueList) (strs []string, items []*Item, strIdx []int, itemIdx []int) {
+	for i, v := range values {
+		switch tv := v.(type) {
+		case string:
+			strs = append(strs, tv)
+			strIdx = append(strIdx, i)
+		case *Item:
+			items = append(items, tv)
+			itemIdx = append(itemIdx, i)
+		}
+	}
+	return strs, items, strIdx, itemIdx
+}
+
+// alignItems pairs each item of b with an item of a. The returned pairs slice
+// holds, for each item of b, the index of its counterpart in a or -1 if it
+// has none. The indexes of items of a without a counterpart are returned in
+// removed.
+func alignItems(a, b []*Item) (pairs []int, removed []int) {
+	pairs = make([]int, len(b))
+	matched := make([]bool, len(a))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+
+	match := func(eq func(x, y *Item) bool) {
+		for bi, bItem := range b {
+			if pairs[bi] >= 0 {
+				continue
+			}
+			for ai, aItem := range a {
+				if !matched[ai] && eq(aItem, bItem) {
+					pairs[bi] = ai
+					matched[ai] = true
+					break
+				}
+			}
+		}
+	}
+
+	// Items with different IDs never describe the same thing.
+	compatible := func(x, y *Item) bool {
+		return x.ID == "" || y.ID == "" || x.ID == y.ID
+	}
+
+	match(func(x, y *Item) bool {
+		return x.ID != "" && x.ID == y.ID
+	})
+	match(func(x, y *Item) bool {
+		if !compatible(x, y) || !sameTypeSet(x.Types, y.Types) {
+			return false
+		}
+		kx, ky := itemKey(x), itemKey(y)
+		return kx != "" && kx == ky
+	})
+	match(func(x, y *Item) bool {
+		return compatible(x, y) && sameTypeSet(x.Types, y.Types)
+	})
+
+	removed = make([]int, 0)
+	for ai := range a {
+		if !matched[ai] {
+			removed = append(removed, ai)
+		}
+	}
+	return pairs, removed
+}
+
+// itemKey returns the name and first value of the first key property the
+// item has.
+func itemKey(item *Item) string {
+	for _, name := range diffKeyProperties {
+		for _, v := range item.Properties[name] {
+			if s, ok := v.(string); ok {
+				return name + "=" + s
+			}
+		}
+	}
+	return ""
+}
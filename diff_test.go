@@ -0,0 +1,102 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffNoChanges(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product">
+	  <span itemprop="name">Widget</span>
+	</div>`
+
+	delta := Diff(ParseData(html, t), ParseData(html, t))
+	if !delta.Empty() {
+		t.Errorf("Expecting no changes but got %v", delta.Changes)
+	}
+}
+
+func TestDiffValueChanges(t *testing.T) {
+	before := `<div itemscope itemtype="http://schema.org/Product" itemid="urn:sku:1">
+	  <span itemprop="name">Widget</span>
+	  <span itemprop="color">Red</span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+	    <span itemprop="price">10.00</span>
+	  </div>
+	</div>`
+
+	after := `<div itemscope itemtype="http://schema.org/Thing">
+	  <span itemprop="name">Unrelated</span>
+	</div>
+	<div itemscope itemtype="http://schema.org/Product" itemid="urn:sku:1">
+	  <span itemprop="name">Widget</span>
+	  <span itemprop="size">Large</span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+	    <span itemprop="price">12.00</span>
+	  </div>
+	</div>`
+
+	delta := Diff(ParseData(before, t), ParseData(after, t))
+
+	expected := `+ [0]: item http://schema.org/Thing
++ [1].size[0]: "Large"
+~ [1].offers[0].price[0]: "10.00" -> "12.00"
+- [1].color[0]: "Red"
+`
+	if actual := delta.Text(); actual != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestDiffTypeChange(t *testing.T) {
+	before := `<div itemscope itemtype="http://schema.org/Article">
+	  <span itemprop="url">http://example.com/a</span>
+	</div>`
+
+	after := `<div itemscope itemtype="http://schema.org/BlogPosting">
+	  <span itemprop="url">http://example.com/a</span>
+	</div>`
+
+	delta := Diff(ParseData(before, t), ParseData(after, t))
+
+	// Items of different types are not aligned.
+	if len(delta.Changes) != 2 || delta.Changes[0].Kind != ItemAdded || delta.Changes[1].Kind != ItemRemoved {
+		t.Fatalf("Expecting an added and a removed item but got %v", delta.Changes)
+	}
+
+	b := NewItem()
+	b.ID = "http://example.com/a"
+	b.AddType("http://schema.org/BlogPosting")
+	a := NewItem()
+	a.ID = "http://example.com/a"
+	a.AddType("http://schema.org/Article")
+
+	setA, setB := NewMicrodata(), NewMicrodata()
+	setA.AddItem(a)
+	setB.AddItem(b)
+
+	actual, _ := Diff(setA, setB).JSON()
+	expectedJSON := []byte(`{"changes":[{"kind":"type-changed","path":"[0]","old":["http://schema.org/Article"],"new":["http://schema.org/BlogPosting"]}]}`)
+	if !bytes.Equal(actual, expectedJSON) {
+		t.Errorf("Expecting %s but got %s", expectedJSON, actual)
+	}
+}
+
+func TestDiffNilSet(t *testing.T) {
+	data := ParseData(`<div itemscope itemtype="http://schema.org/Product"><span itemprop="name">Widget</span></div>`, t)
+
+	if delta := Diff(nil, data); len(delta.Changes) != 1 || delta.Changes[0].Kind != ItemAdded {
+		t.Errorf("Expecting one added item but got %v", delta.Changes)
+	}
+	if delta := Diff(data, nil); len(delta.Changes) != 1 || delta.Changes[0].Kind != ItemRemoved {
+		t.Errorf("Expecting one removed item but got %v", delta.Changes)
+	}
+	if delta := Diff(nil, nil); !delta.Empty() {
+		t.Errorf("Expecting no changes but got %v", delta.Changes)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// dotValueLimit is the number of characters of a string value shown in a DOT
+// node before it is truncated.
+const dotValueLimit = 40
+
+// DOT renders the items as a Graphviz DOT digraph for debugging. Each item is
+// a node whose label is a table headed by its types and ID, followed by a row
+// for each string value. Nested items are linked by edges labelled with the
+// property name. Items reached through more than one property are drawn in
+// blue, and edges that lead back to an ancestor, forming a cycle, are drawn
+// dashed in red.
+func (m *Microdata) DOT() ([]byte, error) {
+	type dotNode struct {
+		id       string
+		item     *Item
+		incoming int
+	}
+	nodes := make([]*dotNode, 0)
+	byItem := make(map[*Item]*dotNode)
+	var edges bytes.Buffer
+
+	node := func(item *Item) *dotNode {
+		n, exists := byItem[item]
+		if !exists {
+			n = &dotNode{id: fmt.Sprintf("item%d", len(nodes)), item: item}
+			byItem[item] = n
+			nodes = append(nodes, n)
+		}
+		return n
+	}
+
+	err := m.Walk(func(v Visit) error {
+		item, ok := v.Value.(*Item)
+		if !ok {
+			return nil
+		}
+		n := node(item)
+		if v.Parent == nil {
+			n.incoming++
+			return nil
+		}
+
+		fmt.Fprintf(&edges, "  %s -> %s [label=%s", byItem[v.Parent].id, n.id, dotQuote(v.Property))
+		if v.Cycle {
+			edges.WriteString(" color=red fontcolor=red style=dashed")
+		}
+		edges.WriteString("];\n")
+
+		if v.Cycle {
+			return nil
+		}
+		n.incoming++
+		if v.Shared {
+			return ErrSkipItem
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph microdata {\n")
+	buf.WriteString("  node [shape=plaintext fontname=\"Helvetica\"];\n")
+	buf.WriteString("  edge [fontname=\"Helvetica\" fontsize=10];\n")
+	for _, n := range nodes {
+		color := "black"
+		if n.incoming > 1 {
+			color = "blue"
+		}
+		fmt.Fprintf(&buf, "  %s [color=%s label=<%s>];\n", n.id, color, dotTable(n.item, color))
+	}
+	buf.Write(edges.Bytes())
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// dotTable returns the HTML-like label of an item's node.
+func dotTable(item *Item, color string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `<table border="0" cellborder="1" cellspacing="0" color="%s">`, color)
+
+	header := make([]string, 0, 2)
+	if len(item.Types) > 0 {
+		header = append(header, "<b>"+dotEscape(strings.Join(item.Types, " "))+"</b>")
+	}
+	if item.ID != "" {
+		header = append(header, dotEscape(item.ID))
+	}
+	if len(header) == 0 {
+		header = append(header, "<i>item</i>")
+	}
+	fmt.Fprintf(&buf, `<tr><td colspan="2" bgcolor="lightgrey">%s</td></tr>`, strings.Join(header, "<br/>"))
+
+	for _, name := range item.PropertyNames() {
+		for _, v := range item.Properties[name] {
+			if s, ok := v.(string); ok {
+				fmt.Fprintf(&buf, `<tr><td align="left">%s</td><td align="left">%s</td></tr>`, dotEscape(name), dotEscape(truncate(cleanText(s), dotValueLimit)))
+			}
+		}
+	}
+	buf.WriteString("</table>")
+	return buf.String()
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis if
+// it was cut.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func dotEscape(s string) string {
+	return html.EscapeString(s)
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
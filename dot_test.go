@@ -0,0 +1,69 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDOT(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+	  <span itemprop="name">Widget &amp; <Co></span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+	    <span itemprop="price">12.50</span>
+	  </div>
+	</div>`
+
+	b, err := ParseData(html, t).DOT()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `digraph microdata {
+  node [shape=plaintext fontname="Helvetica"];
+  edge [fontname="Helvetica" fontsize=10];
+  item0 [color=black label=<<table border="0" cellborder="1" cellspacing="0" color="black"><tr><td colspan="2" bgcolor="lightgrey"><b>http://schema.org/Product</b><br/>http://example.com/widget</td></tr><tr><td align="left">name</td><td align="left">Widget &amp;</td></tr></table>>];
+  item1 [color=black label=<<table border="0" cellborder="1" cellspacing="0" color="black"><tr><td colspan="2" bgcolor="lightgrey"><b>http://schema.org/Offer</b></td></tr><tr><td align="left">price</td><td align="left">12.50</td></tr></table>>];
+  item0 -> item1 [label="offers"];
+}
+`
+	if string(b) != expected {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestDOTSharedAndCyclicItems(t *testing.T) {
+	address := NewItem()
+	address.AddString("streetAddress", strings.Repeat("x", 50))
+	a, b := NewItem(), NewItem()
+	a.AddItem("address", address)
+	a.AddItem("knows", a)
+	b.AddItem("address", address)
+
+	data := NewMicrodata()
+	data.AddItem(a)
+	data.AddItem(b)
+
+	dot, err := data.DOT()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	out := string(dot)
+
+	if !strings.Contains(out, `item0 [color=black`) || !strings.Contains(out, `item1 [color=blue`) {
+		t.Errorf("Expecting only the shared address item to be blue but got %s", out)
+	}
+	if !strings.Contains(out, `item0 -> item0 [label="knows" color=red fontcolor=red style=dashed];`) {
+		t.Errorf("Expecting cyclic edge to be dashed red but got %s", out)
+	}
+	if strings.Count(out, `[label="address"]`) != 2 {
+		t.Errorf("Expecting two address edges but got %s", out)
+	}
+	if !strings.Contains(out, strings.Repeat("x", 39)+"…<") {
+		t.Errorf("Expecting long value to be truncated but got %s", out)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Syntax identifies the markup that items were extracted from.
+type Syntax string
+
+// Syntaxes supported by Extract.
+const (
+	SyntaxMicrodata    Syntax = "microdata"
+	SyntaxJSONLD       Syntax = "json-ld"
+	SyntaxRDFa         Syntax = "rdfa"
+	SyntaxMeta         Syntax = "meta"
+	SyntaxMicroformats Syntax = "microformats2"
+)
+
+// allSyntaxes lists the supported syntaxes in the order their items appear in
+// a Result.
+var allSyntaxes = []Syntax{SyntaxMicrodata, SyntaxJSONLD, SyntaxRDFa, SyntaxMeta, SyntaxMicroformats}
+
+// ExtractOptions configures Extract.
+type ExtractOptions struct {
+	// Syntaxes selects the syntaxes to extract. When empty all are extracted.
+	Syntaxes []Syntax
+
+	// Merge unifies items that describe the same entity across all syntaxes
+	// into Result.Merged. Top-level items are the same entity when they have
+	// the same key, which is the item's ID or, if it has none, its first url
+	// value. An Open Graph item identified by og:url is therefore merged with
+	// a JSON-LD node without an @id whose url is the same. Nested items are
+	// only unified with other items that have the same ID, since a nested
+	// item such as an Offer often shares the url of the item it belongs to.
+	Merge bool
+}
+
+// ExtractedItem is a top-level item tagged with the syntax it came from.
+type ExtractedItem struct {
+	Item   *Item
+	Syntax Syntax
+}
+
+// Result holds the items extracted from a document.
+type Result struct {
+	// Sets holds the items found in each extracted syntax. Syntaxes that were
+	// extracted but had no items have an empty set.
+	Sets map[Syntax]*Microdata
+
+	// Items lists the top-level items of every syntax, tagged with their
+	// origin, with microdata items first followed by JSON-LD, RDFa, meta tag
+	// and microformats2 items.
+	Items []ExtractedItem
+
+	// Merged holds the items of every syntax with those describing the same
+	// entity unified, when ExtractOptions.Merge is set.
+	Merged *Microdata
+
+	// Warnings reports problems that did not prevent extraction, such as
+	// malformed JSON-LD blocks.
+	Warnings []error
+
+	origins map[*Item][]Syntax
+}
+
+// Origins returns the syntaxes that an item of Items or Merged was extracted
+// from. A merged item may have several.
+func (r *Result) Origins(item *Item) []Syntax {
+	return r.origins[item]
+}
+
+// Extract parses an HTML document once and extracts its structured data in
+// every syntax selected by opts. r is a reader over the document and base is
+// the base URL for resolving relative URLs.
+func Extract(r io.Reader, base *url.URL, opts ExtractOptions) (*Result, error) {
+	tree, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := opts.Syntaxes
+	if len(selected) == 0 {
+		selected = allSyntaxes
+	}
+
+	res := &Result{
+		Sets:    make(map[Syntax]*Microdata),
+		Items:   make([]ExtractedItem, 0),
+		origins: make(map[*Item][]Syntax),
+	}
+
+	for _, syntax := range allSyntaxes {
+		if !hasSyntax(selected, syntax) {
+			continue
+		}
+
+		var data *Microdata
+		switch syntax {
+		case SyntaxMicrodata:
+			p := NewParser(nil, base)
+			p.parseTree(tree)
+			data = p.data
+		case SyntaxJSONLD:
+			p := NewJSONLDParser(nil, base)
+			p.parseTree(tree)
+			data = p.data
+			res.Warnings = append(res.Warnings, p.Warnings()...)
+		case SyntaxRDFa:
+			p := NewRDFaParser(nil, base)
+			p.parseTree(tree)
+			data = p.data
+		case SyntaxMeta:
+			p := NewMetaParser(nil, base)
+			p.parseTree(tree)
+			data = p.data
+		case SyntaxMicroformats:
+			p := NewMF2Parser(nil, base)
+			p.parseTree(tree)
+			data = p.data
+		}
+
+		res.Sets[syntax] = data
+		for _, item := range data.Items {
+			res.Items = append(res.Items, ExtractedItem{Item: item, Syntax: syntax})
+			res.origins[item] = []Syntax{syntax}
+		}
+	}
+
+	if opts.Merge {
+		res.merge()
+	}
+	return res, nil
+}
+
+func (r *Result) merge() {
+	sets := make([]*Microdata, 0, len(r.Sets))
+	for _, syntax := range allSyntaxes {
+		if data, exists := r.Sets[syntax]; exists {
+			sets = append(sets, data)
+		}
+	}
+	merged, results := mergeItems(sets, entityKey, func(item *Item) string { return item.ID })
+	r.Merged = merged
+
+	// A merged item comes from the syntaxes of all the items it unifies.
+	for _, extracted := range r.Items {
+		result := results[extracted.Item]
+		if !hasSyntax(r.origins[result], extracted.Syntax) {
+			r.origins[result] = append(r.origins[result], extracted.Syntax)
+		}
+	}
+}
+
+// entityKey returns the item's ID or, lacking one, its first url.
+func entityKey(item *Item) string {
+	if item.ID != "" {
+		return item.ID
+	}
+	return strings.TrimSpace(stringValue(item, "url"))
+}
+
+func hasSyntax(list []Syntax, s Syntax) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const extractHTML = `<html><head>
+<meta property="og:type" content="product">
+<meta property="og:title" content="Widget">
+<meta property="og:url" content="http://example.com/widget">
+<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Product", "url": "http://example.com/widget", "sku": "W-1"}</script>
+<script type="application/ld+json">{ broken</script>
+</head><body>
+<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+  <span itemprop="name">Widget</span>
+</div>
+<div vocab="http://schema.org/" typeof="Organization"><span property="name">Acme</span></div>
+<div class="h-card"><span class="p-name">Jane</span></div>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	u, _ := url.Parse("http://example.com/widget")
+	res, err := Extract(strings.NewReader(extractHTML), u, ExtractOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	syntaxes := make([]Syntax, 0)
+	for _, extracted := range res.Items {
+		syntaxes = append(syntaxes, extracted.Syntax)
+		if origins := res.Origins(extracted.Item); !reflect.DeepEqual(origins, []Syntax{extracted.Syntax}) {
+			t.Errorf("Expecting origin %s but got %v", extracted.Syntax, origins)
+		}
+	}
+	expected := []Syntax{SyntaxMicrodata, SyntaxJSONLD, SyntaxRDFa, SyntaxMeta, SyntaxMicroformats}
+	if !reflect.DeepEqual(syntaxes, expected) {
+		t.Errorf("Expecting items from %v but got %v", expected, syntaxes)
+	}
+
+	if len(res.Sets) != 5 || len(res.Sets[SyntaxRDFa].Items) != 1 {
+		t.Errorf("Expecting a set for each syntax but got %v", res.Sets)
+	}
+	if len(res.Warnings) != 1 {
+		t.Errorf("Expecting 1 warning but got %v", res.Warnings)
+	}
+	if res.Merged != nil {
+		t.Errorf("Expecting no merged items unless requested")
+	}
+}
+
+func TestExtractSelectedSyntaxes(t *testing.T) {
+	u, _ := url.Parse("http://example.com/widget")
+	res, err := Extract(strings.NewReader(extractHTML), u, ExtractOptions{Syntaxes: []Syntax{SyntaxMeta, SyntaxMicrodata}})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if len(res.Sets) != 2 || len(res.Items) != 2 {
+		t.Fatalf("Expecting 2 sets and 2 items but got %d and %d", len(res.Sets), len(res.Items))
+	}
+	if res.Items[0].Syntax != SyntaxMicrodata || res.Items[1].Syntax != SyntaxMeta {
+		t.Errorf("Expecting microdata then meta items but got %v", res.Items)
+	}
+	if len(res.Warnings) != 0 {
+		t.Errorf("Expecting no warnings but got %v", res.Warnings)
+	}
+}
+
+func TestExtractMerge(t *testing.T) {
+	u, _ := url.Parse("http://example.com/widget")
+	res, err := Extract(strings.NewReader(extractHTML), u, ExtractOptions{Merge: true})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if len(res.Merged.Items) != 3 {
+		t.Fatalf("Expecting 3 merged items but got %d", len(res.Merged.Items))
+	}
+
+	widget := res.Merged.Items[0]
+	if widget.ID != "http://example.com/widget" {
+		t.Errorf("Expecting id http://example.com/widget but got %s", widget.ID)
+	}
+	expectedTypes := []string{"http://schema.org/Product", "https://schema.org/Product", "http://ogp.me/ns#product"}
+	if !reflect.DeepEqual(widget.Types, expectedTypes) {
+		t.Errorf("Expecting types %v but got %v", expectedTypes, widget.Types)
+	}
+
+	// the JSON-LD product has no @id so is matched by its url
+	if widget.Properties["sku"][0].(string) != "W-1" || widget.Properties["title"][0].(string) != "Widget" {
+		t.Errorf("Expecting JSON-LD and Open Graph properties to be merged but got %v", widget.Properties)
+	}
+	origins := []Syntax{SyntaxMicrodata, SyntaxJSONLD, SyntaxMeta}
+	if !reflect.DeepEqual(res.Origins(widget), origins) {
+		t.Errorf("Expecting origins %v but got %v", origins, res.Origins(widget))
+	}
+
+	if origins := res.Origins(res.Merged.Items[1]); !reflect.DeepEqual(origins, []Syntax{SyntaxRDFa}) {
+		t.Errorf("Expecting unmerged item to keep its origin but got %v", origins)
+	}
+}
+
+func TestExtractMergeNestedURL(t *testing.T) {
+	html := `<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Product", "url": "http://example.com/p/1",
+	  "offers": {"@type": "Offer", "url": "http://example.com/p/1", "price": "10"}}</script>
+	<div itemscope itemtype="http://schema.org/Product"><link itemprop="url" href="/p/1"><span itemprop="name">Widget</span></div>`
+
+	u, _ := url.Parse("http://example.com/p/1")
+	res, err := Extract(strings.NewReader(html), u, ExtractOptions{Merge: true})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if len(res.Merged.Items) != 1 {
+		t.Fatalf("Expecting 1 merged item but got %d", len(res.Merged.Items))
+	}
+	product := res.Merged.Items[0]
+	if len(product.Types) != 2 || product.Properties["name"][0].(string) != "Widget" {
+		t.Errorf("Expecting the products to be merged but got %v %v", product.Types, product.Properties)
+	}
+
+	offer, ok := product.Properties["offers"][0].(*Item)
+	if !ok || offer == product || !reflect.DeepEqual(offer.Types, []string{"https://schema.org/Offer"}) {
+		t.Errorf("Expecting the offer to stay a separate item but got %v", product.Properties["offers"])
+	}
+
+	if _, err := res.Merged.JSON(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
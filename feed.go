@@ -0,0 +1,325 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FeedInfo describes a feed built from Article items.
+type FeedInfo struct {
+	Title       string
+	Link        string // URL of the site the feed describes
+	ID          string // Atom feed id, defaulting to Link
+	Description string
+	Author      string // name of the Atom feed author, required if an entry has none
+}
+
+// FeedEntry is an entry in a feed built from an Article item. Its ID is the
+// item's ID or url or, lacking both, a urn:sha256: URN made from Item.Hash so
+// that it is the same each time the feed is built.
+type FeedEntry struct {
+	ID        string
+	Title     string
+	URL       string
+	Summary   string
+	Image     string
+	Authors   []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// FeedEntries converts the Article items in the given sets, including those
+// of subtypes such as BlogPosting and NewsArticle and nested ones such as the
+// posts of a Blog, into feed entries. Items describing the same article, by
+// ID, url or content, are included once. Entries are sorted newest first by publication
+// date, falling back to modification date, with undated entries last.
+func FeedEntries(sets ...*Microdata) []*FeedEntry {
+	entries := make([]*FeedEntry, 0)
+	seen := make(map[string]bool)
+
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		set.Walk(func(v Visit) error {
+			item, ok := v.Value.(*Item)
+			if !ok || v.Shared || !isSchemaType(item, "Article") {
+				return nil
+			}
+
+			e := feedEntry(item)
+			if seen[e.ID] {
+				return nil
+			}
+			seen[e.ID] = true
+			entries = append(entries, e)
+			return nil
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].date().After(entries[j].date())
+	})
+	return entries
+}
+
+func feedEntry(item *Item) *FeedEntry {
+	e := &FeedEntry{
+		Title:   cleanText(stringValue(item, "headline", "name")),
+		URL:     strings.TrimSpace(stringValue(item, "url", "mainEntityOfPage")),
+		Summary: cleanText(stringValue(item, "description", "abstract")),
+		Image:   strings.TrimSpace(urlValue(firstValue(item, "image"))),
+	}
+
+	e.ID = item.ID
+	setIfEmpty(&e.ID, e.URL)
+	setIfEmpty(&e.ID, "urn:sha256:"+item.Hash())
+
+	for _, v := range item.Properties["author"] {
+		if name := cleanText(textValue(v)); name != "" {
+			e.Authors = append(e.Authors, name)
+		}
+	}
+
+	e.Published = feedTime(stringValue(item, "datePublished", "dateCreated"))
+	e.Updated = feedTime(stringValue(item, "dateModified"))
+	return e
+}
+
+// date returns the time used to order entries.
+func (e *FeedEntry) date() time.Time {
+	if !e.Published.IsZero() {
+		return e.Published
+	}
+	return e.Updated
+}
+
+// updated returns the time the entry was last changed.
+func (e *FeedEntry) updated() time.Time {
+	if !e.Updated.IsZero() {
+		return e.Updated
+	}
+	return e.Published
+}
+
+// feedTime parses a date or date and time, treating those without a time
+// zone as UTC.
+func feedTime(s string) time.Time {
+	t, _, ok := parseDateTime(s)
+	if !ok {
+		return time.Time{}
+	}
+	return t
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string       `xml:"title"`
+	Link    []atomLink   `xml:"link"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Authors []atomPerson `xml:"author"`
+	Entries []atomEntry  `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string       `xml:"title"`
+	Link      []atomLink   `xml:"link"`
+	ID        string       `xml:"id"`
+	Published string       `xml:"published,omitempty"`
+	Updated   string       `xml:"updated"`
+	Authors   []atomPerson `xml:"author"`
+	Summary   string       `xml:"summary,omitempty"`
+}
+
+// AtomFeed builds an Atom 1.0 feed from the Article items in the given sets.
+// See FeedEntries for how entries are chosen and ordered. Entries without a
+// date are given the feed's updated time, which is that of the newest entry or
+// the current time if no entry has a date. Without an ID or Link the feed id
+// is a urn:sha256: URN made from the title and description. Atom requires an
+// author for every entry, so an error is returned if an entry has none and
+// info has no Author.
+func AtomFeed(info FeedInfo, sets ...*Microdata) ([]byte, error) {
+	entries := FeedEntries(sets...)
+
+	if info.Author == "" {
+		for _, e := range entries {
+			if len(e.Authors) == 0 {
+				return nil, fmt.Errorf("microdata: Atom entry %s has no author and the feed has no Author", e.ID)
+			}
+		}
+	}
+
+	var updated time.Time
+	for _, e := range entries {
+		if t := e.updated(); t.After(updated) {
+			updated = t
+		}
+	}
+	if updated.IsZero() {
+		updated = now().UTC()
+	}
+
+	feed := atomFeed{
+		Title:   info.Title,
+		ID:      info.ID,
+		Updated: updated.Format(time.RFC3339),
+		Entries: make([]atomEntry, 0, len(entries)),
+	}
+	setIfEmpty(&feed.ID, info.Link)
+	if feed.ID == "" {
+		sum := sha256.Sum256([]byte(info.Title + "\n" + info.Description))
+		feed.ID = "urn:sha256:" + hex.EncodeToString(sum[:])
+	}
+	if info.Author != "" {
+		feed.Authors = append(feed.Authors, atomPerson{Name: info.Author})
+	}
+	if info.Link != "" {
+		feed.Link = append(feed.Link, atomLink{Href: info.Link, Rel: "alternate"})
+	}
+
+	for _, e := range entries {
+		ae := atomEntry{
+			Title:   e.Title,
+			ID:      e.ID,
+			Updated: updated.Format(time.RFC3339),
+			Summary: e.Summary,
+		}
+		if t := e.updated(); !t.IsZero() {
+			ae.Updated = t.Format(time.RFC3339)
+		}
+		if !e.Published.IsZero() {
+			ae.Published = e.Published.Format(time.RFC3339)
+		}
+		if e.URL != "" {
+			ae.Link = append(ae.Link, atomLink{Href: e.URL, Rel: "alternate"})
+		}
+		if e.Image != "" {
+			ae.Link = append(ae.Link, atomLink{Href: e.Image, Rel: "enclosure", Type: imageType(e.Image)})
+		}
+		for _, name := range e.Authors {
+			ae.Authors = append(ae.Authors, atomPerson{Name: name})
+		}
+		feed.Entries = append(feed.Entries, ae)
+	}
+
+	b, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title,omitempty"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Creators    []string      `xml:"dc:creator"`
+	GUID        *rssGUID      `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// RSSFeed builds an RSS 2.0 feed from the Article items in the given sets.
+// See FeedEntries for how entries are chosen and ordered. Authors are written
+// as dc:creator elements since RSS requires an email address in author.
+func RSSFeed(info FeedInfo, sets ...*Microdata) ([]byte, error) {
+	entries := FeedEntries(sets...)
+
+	feed := rssFeed{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       info.Title,
+			Link:        info.Link,
+			Description: info.Description,
+			Items:       make([]rssItem, 0, len(entries)),
+		},
+	}
+
+	for _, e := range entries {
+		ri := rssItem{
+			Title:       e.Title,
+			Link:        e.URL,
+			Description: e.Summary,
+			Creators:    e.Authors,
+		}
+		if e.ID != "" {
+			ri.GUID = &rssGUID{Value: e.ID, IsPermaLink: e.ID == e.URL}
+		}
+		if t := e.date(); !t.IsZero() {
+			ri.PubDate = t.Format(time.RFC1123Z)
+		}
+		if e.Image != "" {
+			if t := imageType(e.Image); t != "" {
+				ri.Enclosure = &rssEnclosure{URL: e.Image, Length: "0", Type: t}
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, ri)
+	}
+
+	b, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// imageType guesses the media type of an image from its URL.
+func imageType(u string) string {
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	t := mime.TypeByExtension(strings.ToLower(path.Ext(u)))
+	if !strings.HasPrefix(t, "image/") {
+		return ""
+	}
+	return t
+}
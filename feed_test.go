@@ -0,0 +1,152 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"strings"
+	"testing"
+)
+
+const blogHTML = `<div itemscope itemtype="http://schema.org/Blog">
+  <article itemprop="blogPost" itemscope itemtype="http://schema.org/BlogPosting">
+    <a itemprop="url" href="/posts/1"><h2 itemprop="headline">First post</h2></a>
+    <time itemprop="datePublished" datetime="2024-01-01T09:00:00Z">Jan 1</time>
+    <span itemprop="author" itemscope itemtype="http://schema.org/Person"><span itemprop="name">Jane Doe</span></span>
+  </article>
+  <article itemprop="blogPost" itemscope itemtype="http://schema.org/BlogPosting">
+    <a itemprop="url" href="/posts/2"><h2 itemprop="headline">Second post</h2></a>
+    <time itemprop="datePublished" datetime="2024-02-01T09:00:00Z">Feb 1</time>
+    <time itemprop="dateModified" datetime="2024-02-03T10:00:00Z">Feb 3</time>
+    <p itemprop="description">More news & updates.</p>
+    <img itemprop="image" src="/posts/2.png">
+  </article>
+</div>`
+
+func TestFeedEntries(t *testing.T) {
+	data := ParseData(blogHTML, t)
+
+	// The same posts seen on another page are only included once.
+	entries := FeedEntries(data, ParseData(blogHTML, t))
+
+	if len(entries) != 2 {
+		t.Fatalf("Expecting 2 entries but got %d", len(entries))
+	}
+	if entries[0].Title != "Second post" || entries[1].Title != "First post" {
+		t.Errorf("Expecting newest entry first but got %q, %q", entries[0].Title, entries[1].Title)
+	}
+	if len(entries[1].Authors) != 1 || entries[1].Authors[0] != "Jane Doe" {
+		t.Errorf("Expecting author 'Jane Doe' but got %v", entries[1].Authors)
+	}
+}
+
+func TestFeedNilSets(t *testing.T) {
+	if entries := FeedEntries(nil, ParseData(blogHTML, t), nil); len(entries) != 2 {
+		t.Errorf("Expecting 2 entries but got %d", len(entries))
+	}
+	if _, err := AtomFeed(FeedInfo{Title: "Blog", Author: "Blog Team"}, nil); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if _, err := RSSFeed(FeedInfo{Title: "Blog"}, nil); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
+
+func TestAtomFeed(t *testing.T) {
+	b, err := AtomFeed(FeedInfo{Title: "Blog", Link: "http://example.com/", Author: "Blog Team"}, ParseData(blogHTML, t))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Blog</title>
+  <link href="http://example.com/" rel="alternate"></link>
+  <id>http://example.com/</id>
+  <updated>2024-02-03T10:00:00Z</updated>
+  <author>
+    <name>Blog Team</name>
+  </author>
+  <entry>
+    <title>Second post</title>
+    <link href="http://example.com/posts/2" rel="alternate"></link>
+    <link href="http://example.com/posts/2.png" rel="enclosure" type="image/png"></link>
+    <id>http://example.com/posts/2</id>
+    <published>2024-02-01T09:00:00Z</published>
+    <updated>2024-02-03T10:00:00Z</updated>
+    <summary>More news &amp; updates.</summary>
+  </entry>
+  <entry>
+    <title>First post</title>
+    <link href="http://example.com/posts/1" rel="alternate"></link>
+    <id>http://example.com/posts/1</id>
+    <published>2024-01-01T09:00:00Z</published>
+    <updated>2024-01-01T09:00:00Z</updated>
+    <author>
+      <name>Jane Doe</name>
+    </author>
+  </entry>
+</feed>`
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
+
+func TestAtomFeedRequiredElements(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/BlogPosting"><h2 itemprop="headline">Untitled</h2></div>`
+
+	if _, err := AtomFeed(FeedInfo{Title: "Blog"}, ParseData(html, t)); err == nil {
+		t.Errorf("Expecting an error for an entry without an author")
+	}
+
+	b, err := AtomFeed(FeedInfo{Title: "Blog", Author: "Blog Team"}, ParseData(html, t))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	again, _ := AtomFeed(FeedInfo{Title: "Blog", Author: "Blog Team"}, ParseData(html, t))
+	if string(b) != string(again) {
+		t.Errorf("Expecting generated ids to be stable but got:\n%s\nthen:\n%s", b, again)
+	}
+	if strings.Contains(string(b), "<id></id>") || strings.Count(string(b), "<id>urn:sha256:") != 2 {
+		t.Errorf("Expecting generated feed and entry ids but got:\n%s", b)
+	}
+	if !strings.Contains(string(b), "<author>\n    <name>Blog Team</name>\n  </author>") {
+		t.Errorf("Expecting feed author but got:\n%s", b)
+	}
+}
+
+func TestRSSFeed(t *testing.T) {
+	b, err := RSSFeed(FeedInfo{Title: "Blog", Link: "http://example.com/", Description: "Posts"}, ParseData(blogHTML, t))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Blog</title>
+    <link>http://example.com/</link>
+    <description>Posts</description>
+    <item>
+      <title>Second post</title>
+      <link>http://example.com/posts/2</link>
+      <description>More news &amp; updates.</description>
+      <guid isPermaLink="true">http://example.com/posts/2</guid>
+      <pubDate>Thu, 01 Feb 2024 09:00:00 +0000</pubDate>
+      <enclosure url="http://example.com/posts/2.png" length="0" type="image/png"></enclosure>
+    </item>
+    <item>
+      <title>First post</title>
+      <link>http://example.com/posts/1</link>
+      <dc:creator>Jane Doe</dc:creator>
+      <guid isPermaLink="true">http://example.com/posts/1</guid>
+      <pubDate>Mon, 01 Jan 2024 09:00:00 +0000</pubDate>
+    </item>
+  </channel>
+</rss>`
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string             `json:"type"`
+	Coordinates interface{}        `json:"coordinates,omitempty"`
+	Geometries  []*geoJSONGeometry `json:"geometries,omitempty"`
+}
+
+// geoProperties are the properties that hold an item's location and so are
+// not copied into feature properties.
+var geoProperties = map[string]bool{"geo": true, "latitude": true, "longitude": true, "elevation": true}
+
+// GeoJSON converts the items in the set that have a location into a GeoJSON
+// FeatureCollection as defined by RFC 7946. An item has a location when it has
+// a geo property holding a GeoCoordinates or GeoShape item, or has latitude and
+// longitude properties of its own. Boxes and polygons become Polygon
+// geometries, lines become LineStrings and circles become Points with a radius
+// feature property. The item's types and other string properties are carried
+// as feature properties. Coordinates that are not finite numbers, or whose
+// latitude or longitude is out of range, are not a location.
+func (m *Microdata) GeoJSON() ([]byte, error) {
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0)}
+
+	err := m.Walk(func(v Visit) error {
+		item, ok := v.Value.(*Item)
+		if !ok || v.Shared || isSchemaType(item, "GeoCoordinates", "GeoShape") {
+			return nil
+		}
+		if feature, ok := itemFeature(item); ok {
+			collection.Features = append(collection.Features, feature)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(collection)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func itemFeature(item *Item) (geoJSONFeature, bool) {
+	properties := make(map[string]interface{})
+	geometries := make([]*geoJSONGeometry, 0)
+
+	if g := pointGeometry(item); g != nil {
+		geometries = append(geometries, g)
+	}
+	for _, v := range item.Properties["geo"] {
+		if geo, ok := v.(*Item); ok {
+			if g := pointGeometry(geo); g != nil {
+				geometries = append(geometries, g)
+			}
+			if g, radius := shapeGeometry(geo); g != nil {
+				geometries = append(geometries, g)
+				if radius != "" {
+					properties["radius"] = radius
+				}
+			}
+		}
+	}
+
+	if len(geometries) == 0 {
+		return geoJSONFeature{}, false
+	}
+
+	feature := geoJSONFeature{Type: "Feature", ID: item.ID, Geometry: geometries[0], Properties: properties}
+	if len(geometries) > 1 {
+		feature.Geometry = &geoJSONGeometry{Type: "GeometryCollection", Geometries: geometries}
+	}
+
+	if len(item.Types) > 0 {
+		properties["@type"] = item.Types
+	}
+	for _, name := range item.PropertyNames() {
+		if geoProperties[name] {
+			continue
+		}
+		values := stringValues(item, name)
+		switch len(values) {
+		case 0:
+		case 1:
+			properties[name] = cleanText(values[0])
+		default:
+			cleaned := make([]string, len(values))
+			for i, s := range values {
+				cleaned[i] = cleanText(s)
+			}
+			properties[name] = cleaned
+		}
+	}
+
+	return feature, true
+}
+
+// pointGeometry returns a Point for an item with latitude and longitude
+// properties, including the elevation when it is known.
+func pointGeometry(item *Item) *geoJSONGeometry {
+	position, ok := parsePosition(stringValue(item, "latitude"), stringValue(item, "longitude"))
+	if !ok {
+		return nil
+	}
+	if elevation, ok := parseCoordinate(stringValue(item, "elevation"), math.MaxFloat64); ok {
+		position = append(position, elevation)
+	}
+	return &geoJSONGeometry{Type: "Point", Coordinates: position}
+}
+
+// shapeGeometry returns the geometry described by a GeoShape item along with
+// the radius of a circle.
+func shapeGeometry(item *Item) (*geoJSONGeometry, string) {
+	if points, ok := parsePoints(stringValue(item, "box")); ok && len(points) == 2 {
+		sw, ne := points[0], points[1]
+		ring := [][]float64{sw, {ne[0], sw[1]}, ne, {sw[0], ne[1]}, sw}
+		return &geoJSONGeometry{Type: "Polygon", Coordinates: [][][]float64{ring}}, ""
+	}
+
+	if points, ok := parsePoints(stringValue(item, "polygon")); ok && len(points) >= 3 {
+		first, last := points[0], points[len(points)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			points = append(points, first)
+		}
+		return &geoJSONGeometry{Type: "Polygon", Coordinates: [][][]float64{points}}, ""
+	}
+
+	if points, ok := parsePoints(stringValue(item, "line")); ok && len(points) >= 2 {
+		return &geoJSONGeometry{Type: "LineString", Coordinates: points}, ""
+	}
+
+	fields := strings.FieldsFunc(stringValue(item, "circle"), isCoordinateSeparator)
+	if len(fields) == 3 {
+		if points, ok := parsePoints(strings.Join(fields[:2], " ")); ok {
+			return &geoJSONGeometry{Type: "Point", Coordinates: points[0]}, fields[2]
+		}
+	}
+
+	return nil, ""
+}
+
+// parsePoints parses a list of space or comma separated latitude and
+// longitude pairs, as used by GeoShape, into GeoJSON positions.
+func parsePoints(s string) ([][]float64, bool) {
+	fields := strings.FieldsFunc(s, isCoordinateSeparator)
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return nil, false
+	}
+
+	points := make([][]float64, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		position, ok := parsePosition(fields[i], fields[i+1])
+		if !ok {
+			return nil, false
+		}
+		points = append(points, position)
+	}
+	return points, true
+}
+
+// parsePosition parses a latitude and longitude into a GeoJSON position. It
+// reports false unless both are finite numbers within range.
+func parsePosition(latitude, longitude string) ([]float64, bool) {
+	lat, ok := parseCoordinate(latitude, 90)
+	if !ok {
+		return nil, false
+	}
+	lon, ok := parseCoordinate(longitude, 180)
+	if !ok {
+		return nil, false
+	}
+	return []float64{lon, lat}, true
+}
+
+// parseCoordinate parses a finite number no further than limit from zero.
+func parseCoordinate(s string, limit float64) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) > limit {
+		return 0, false
+	}
+	return f, true
+}
+
+func isCoordinateSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
@@ -0,0 +1,84 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGeoJSONPoint(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/LocalBusiness" itemid="http://example.com/stores/1">
+	  <span itemprop="name">Corner Store</span>
+	  <span itemprop="telephone">555-0100</span>
+	  <div itemprop="geo" itemscope itemtype="http://schema.org/GeoCoordinates">
+	    <meta itemprop="latitude" content="40.75">
+	    <meta itemprop="longitude" content="-73.98">
+	  </div>
+	</div>
+	<div itemscope itemtype="http://schema.org/Thing">
+	  <span itemprop="name">Nowhere</span>
+	</div>`
+
+	b, err := ParseData(html, t).GeoJSON()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []byte(`{"type":"FeatureCollection","features":[{"type":"Feature","id":"http://example.com/stores/1","geometry":{"type":"Point","coordinates":[-73.98,40.75]},"properties":{"@type":["http://schema.org/LocalBusiness"],"name":"Corner Store","telephone":"555-0100"}}]}`)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestGeoJSONShapes(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Place">
+	  <div itemprop="geo" itemscope itemtype="http://schema.org/GeoShape">
+	    <meta itemprop="box" content="10 20 11 21">
+	  </div>
+	</div>
+	<div itemscope itemtype="http://schema.org/Place">
+	  <div itemprop="geo" itemscope itemtype="http://schema.org/GeoShape">
+	    <meta itemprop="circle" content="10,20 500">
+	  </div>
+	</div>`
+
+	b, err := ParseData(html, t).GeoJSON()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []byte(`{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[20,10],[21,10],[21,11],[20,11],[20,10]]]},"properties":{"@type":["http://schema.org/Place"]}},` +
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[20,10]},"properties":{"@type":["http://schema.org/Place"],"radius":"500"}}]}`)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestGeoJSONInvalidCoordinates(t *testing.T) {
+	place := func(lat, lon string) string {
+		return `<div itemscope itemtype="http://schema.org/Place">
+		  <div itemprop="geo" itemscope itemtype="http://schema.org/GeoCoordinates">
+		    <meta itemprop="latitude" content="` + lat + `"><meta itemprop="longitude" content="` + lon + `">
+		  </div>
+		</div>`
+	}
+	html := place("NaN", "1") + place("1", "Inf") + place("91", "0") + place("0", "-180.5") +
+		place("1", "2") + `<div itemscope itemtype="http://schema.org/Place">
+		  <div itemprop="geo" itemscope itemtype="http://schema.org/GeoShape"><meta itemprop="line" content="0 0 95 0"></div>
+		</div>`
+
+	b, err := ParseData(html, t).GeoJSON()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []byte(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[2,1]},"properties":{"@type":["http://schema.org/Place"]}}]}`)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
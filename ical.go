@@ -0,0 +1,235 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// icalDuration matches the ISO 8601 durations that are also valid iCalendar
+// durations, which cannot contain years, months or fractions.
+var icalDuration = regexp.MustCompile(`^P(\d+W|(\d+D)?(T(\d+H)?(\d+M)?(\d+S)?)?)$`)
+
+// icalStatus maps schema.org EventStatusType members to iCalendar STATUS
+// values.
+var icalStatus = map[string]string{
+	"EventScheduled":   "CONFIRMED",
+	"EventMovedOnline": "CONFIRMED",
+	"EventRescheduled": "CONFIRMED",
+	"EventPostponed":   "TENTATIVE",
+	"EventCancelled":   "CANCELLED",
+}
+
+// ICalendar converts the schema.org Event items in the set, including those of
+// its subtypes and nested events, into a single VCALENDAR as defined by RFC
+// 5545 with one VEVENT per event. Events without a start date are omitted
+// since a VEVENT requires one.
+func (m *Microdata) ICalendar() ([]byte, error) {
+	var buf bytes.Buffer
+	stamp := now().UTC().Format("20060102T150405Z")
+
+	writeContentLine(&buf, "BEGIN", "VCALENDAR")
+	writeContentLine(&buf, "VERSION", "2.0")
+	writeContentLine(&buf, "PRODID", "-//iand//microdata//EN")
+
+	err := m.Walk(func(v Visit) error {
+		item, ok := v.Value.(*Item)
+		if !ok || v.Shared || !isSchemaType(item, "Event") {
+			return nil
+		}
+		writeEvent(&buf, item, stamp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writeContentLine(&buf, "END", "VCALENDAR")
+	return buf.Bytes(), nil
+}
+
+func writeEvent(buf *bytes.Buffer, item *Item, stamp string) {
+	startParam, start, ok := icalDateTime(stringValue(item, "startDate"))
+	if !ok {
+		return
+	}
+
+	writeContentLine(buf, "BEGIN", "VEVENT")
+
+	uid := item.ID
+	if uid == "" {
+		uid = strings.TrimSpace(stringValue(item, "url"))
+	}
+	if uid == "" {
+		uid = item.Hash() + "@microdata"
+	}
+	writeContentLine(buf, "UID", escapeText(uid))
+	writeContentLine(buf, "DTSTAMP", stamp)
+	writeContentLine(buf, "DTSTART"+startParam, start)
+
+	if endParam, end, ok := icalEnd(stringValue(item, "startDate"), stringValue(item, "endDate")); ok {
+		writeContentLine(buf, "DTEND"+endParam, end)
+	} else if duration := strings.TrimSpace(stringValue(item, "duration")); isICalDuration(duration) {
+		writeContentLine(buf, "DURATION", duration)
+	}
+
+	// A VEVENT may have only one SUMMARY and one DESCRIPTION.
+	writeTextLines(buf, "SUMMARY", []string{firstNonEmpty(stringValues(item, "name"))})
+	writeTextLines(buf, "DESCRIPTION", []string{firstNonEmpty(stringValues(item, "description"))})
+
+	if location := firstValue(item, "location"); location != nil {
+		writeLocation(buf, location)
+	}
+
+	if organizer := firstValue(item, "organizer"); organizer != nil {
+		writeOrganizer(buf, organizer)
+	}
+
+	if u := strings.TrimSpace(stringValue(item, "url")); u != "" {
+		writeContentLine(buf, "URL", u)
+	}
+
+	if status, exists := icalStatus[enumValue(stringValue(item, "eventStatus"))]; exists {
+		writeContentLine(buf, "STATUS", status)
+	}
+
+	writeContentLine(buf, "END", "VEVENT")
+}
+
+// writeLocation writes the LOCATION of an event from a text value, a Place,
+// a PostalAddress or a VirtualLocation, adding GEO when a Place has
+// coordinates.
+func writeLocation(buf *bytes.Buffer, location interface{}) {
+	place, ok := location.(*Item)
+	if !ok {
+		writeTextLines(buf, "LOCATION", []string{textValue(location)})
+		return
+	}
+
+	if isSchemaType(place, "VirtualLocation") {
+		writeTextLines(buf, "LOCATION", []string{stringValue(place, "url", "name")})
+		return
+	}
+
+	parts := make([]string, 0)
+	if isSchemaType(place, "PostalAddress") {
+		parts = append(parts, addressParts(place)...)
+	} else {
+		if name := cleanText(stringValue(place, "name")); name != "" {
+			parts = append(parts, name)
+		}
+		switch address := firstValue(place, "address").(type) {
+		case string:
+			parts = append(parts, cleanText(address))
+		case *Item:
+			parts = append(parts, addressParts(address)...)
+		}
+	}
+	writeTextLines(buf, "LOCATION", []string{strings.Join(parts, ", ")})
+
+	if geo := itemValue(place, "geo"); geo != nil {
+		lat, lon := cleanText(stringValue(geo, "latitude")), cleanText(stringValue(geo, "longitude"))
+		if lat != "" && lon != "" {
+			writeContentLine(buf, "GEO", lat+";"+lon)
+		}
+	}
+}
+
+func addressParts(address *Item) []string {
+	parts := make([]string, 0)
+	for _, name := range []string{"streetAddress", "addressLocality", "addressRegion", "postalCode", "addressCountry"} {
+		if part := cleanText(textValue(firstValue(address, name))); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// writeOrganizer writes the ORGANIZER of an event. The value must be a URI so
+// an email address is preferred, falling back to the organizer's url.
+func writeOrganizer(buf *bytes.Buffer, organizer interface{}) {
+	var name, address string
+	switch tv := organizer.(type) {
+	case string:
+		address = cleanText(tv)
+	case *Item:
+		name = cleanText(stringValue(tv, "name"))
+		if email := cleanText(stringValue(tv, "email")); email != "" {
+			address = "mailto:" + strings.TrimPrefix(email, "mailto:")
+		} else {
+			address = cleanText(stringValue(tv, "url"))
+		}
+	}
+
+	if !strings.Contains(address, ":") {
+		return
+	}
+	property := "ORGANIZER"
+	if name != "" {
+		property += ";CN=" + paramValue(name)
+	}
+	writeContentLine(buf, property, address)
+}
+
+// icalEnd converts the end date of an event into a DTEND of the same value
+// type as its DTSTART, as RFC 5545 requires. A schema.org end date includes
+// its last day while DTEND does not, so an event starting on a date ends on
+// the day after its last one. An end that is not after the start is ignored,
+// as is a date end for an event starting at a time.
+func icalEnd(startValue, endValue string) (param string, value string, ok bool) {
+	start, startKind, ok := parseDateTime(startValue)
+	if !ok {
+		return "", "", false
+	}
+	end, endKind, ok := parseDateTime(endValue)
+	if !ok {
+		return "", "", false
+	}
+
+	if startKind == dateOnly {
+		last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+		if endKind != dateOnly && end.Equal(time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())) {
+			// an event ending at midnight ends on the previous day
+			last = last.AddDate(0, 0, -1)
+		}
+		dtend := last.AddDate(0, 0, 1)
+		if !dtend.After(start) {
+			return "", "", false
+		}
+		return ";VALUE=DATE", dtend.Format("20060102"), true
+	}
+
+	if endKind == dateOnly || !end.After(start) {
+		return "", "", false
+	}
+	return icalDateTime(endValue)
+}
+
+// isICalDuration reports whether s is an ISO 8601 duration that is also a
+// valid, non-empty iCalendar duration.
+func isICalDuration(s string) bool {
+	return icalDuration.MatchString(s) && s != "P" && !strings.HasSuffix(s, "T")
+}
+
+// icalDateTime converts a date or date and time into an iCalendar DATE or
+// DATE-TIME along with the VALUE parameter it needs. Times with an offset are
+// converted to UTC and times without one are written as floating times.
+func icalDateTime(s string) (param string, value string, ok bool) {
+	t, kind, ok := parseDateTime(s)
+	if !ok {
+		return "", "", false
+	}
+	switch kind {
+	case dateOnly:
+		return ";VALUE=DATE", t.Format("20060102"), true
+	case zonedTime:
+		return "", t.UTC().Format("20060102T150405Z"), true
+	}
+	return "", t.Format("20060102T150405"), true
+}
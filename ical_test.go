@@ -0,0 +1,131 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalendar(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	html := `<div itemscope itemtype="http://schema.org/MusicEvent">
+	  <a itemprop="url" href="/events/1"><span itemprop="name">Jazz Night</span></a>
+	  <time itemprop="startDate" datetime="2024-05-10T19:30:00+02:00">May 10, 7:30pm</time>
+	  <meta itemprop="duration" content="PT2H30M">
+	  <link itemprop="eventStatus" href="https://schema.org/EventPostponed">
+	  <div itemprop="location" itemscope itemtype="http://schema.org/Place">
+	    <span itemprop="name">The Blue Room</span>
+	    <div itemprop="address" itemscope itemtype="http://schema.org/PostalAddress">
+	      <span itemprop="streetAddress">1 Main St</span>, <span itemprop="addressLocality">Springfield</span>
+	    </div>
+	    <div itemprop="geo" itemscope itemtype="http://schema.org/GeoCoordinates">
+	      <meta itemprop="latitude" content="40.75">
+	      <meta itemprop="longitude" content="-73.98">
+	    </div>
+	  </div>
+	  <div itemprop="organizer" itemscope itemtype="http://schema.org/Organization">
+	    <span itemprop="name">Jazz Society; Springfield</span>
+	    <span itemprop="email">info@example.com</span>
+	  </div>
+	</div>
+	<div itemscope itemtype="http://schema.org/Event">
+	  <span itemprop="name">No date</span>
+	</div>`
+
+	b, err := ParseData(html, t).ICalendar()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//iand//microdata//EN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:http://example.com/events/1\r\n" +
+		"DTSTAMP:20240102T030405Z\r\n" +
+		"DTSTART:20240510T173000Z\r\n" +
+		"DURATION:PT2H30M\r\n" +
+		"SUMMARY:Jazz Night\r\n" +
+		"LOCATION:The Blue Room\\, 1 Main St\\, Springfield\r\n" +
+		"GEO:40.75;-73.98\r\n" +
+		"ORGANIZER;CN=\"Jazz Society; Springfield\":mailto:info@example.com\r\n" +
+		"URL:http://example.com/events/1\r\n" +
+		"STATUS:TENTATIVE\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
+
+func TestICalendarDates(t *testing.T) {
+	testCases := []struct {
+		start, end string
+		expected   []string
+	}{
+		// an end date is the last day of the event, so DTEND is the day after
+		{"2024-07-01", "2024-07-03", []string{"DTSTART;VALUE=DATE:20240701\r\n", "DTEND;VALUE=DATE:20240704\r\n"}},
+		{"2024-07-01", "2024-07-01", []string{"DTSTART;VALUE=DATE:20240701\r\n", "DTEND;VALUE=DATE:20240702\r\n"}},
+		// a time end is coerced to a date to match the start
+		{"2024-07-01", "2024-07-03 18:00", []string{"DTSTART;VALUE=DATE:20240701\r\n", "DTEND;VALUE=DATE:20240704\r\n"}},
+		{"2024-07-01", "2024-07-04T00:00", []string{"DTSTART;VALUE=DATE:20240701\r\n", "DTEND;VALUE=DATE:20240704\r\n"}},
+		{"2024-07-01T10:00", "2024-07-01T12:00", []string{"DTSTART:20240701T100000\r\n", "DTEND:20240701T120000\r\n"}},
+		// a date end cannot be written for a time start
+		{"2024-07-01T10:00", "2024-07-03", []string{"DTSTART:20240701T100000\r\n"}},
+		{"2024-07-03", "2024-07-01", []string{"DTSTART;VALUE=DATE:20240703\r\n"}},
+	}
+
+	for _, tc := range testCases {
+		html := `<div itemscope itemtype="http://schema.org/Festival">
+		  <span itemprop="name">Summer Fest</span>
+		  <meta itemprop="startDate" content="` + tc.start + `">
+		  <meta itemprop="endDate" content="` + tc.end + `">
+		</div>`
+
+		b, err := ParseData(html, t).ICalendar()
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+
+		for _, line := range tc.expected {
+			if !strings.Contains(string(b), line) {
+				t.Errorf("Expecting line %q for %s to %s in:\n%s", line, tc.start, tc.end, b)
+			}
+		}
+		if len(tc.expected) == 1 && strings.Contains(string(b), "DTEND") {
+			t.Errorf("Expecting no DTEND for %s to %s in:\n%s", tc.start, tc.end, b)
+		}
+	}
+}
+
+func TestICalendarSingleSummary(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Event">
+	  <span itemprop="name"> </span>
+	  <span itemprop="name">A</span>
+	  <span itemprop="name">B</span>
+	  <span itemprop="description">First</span>
+	  <span itemprop="description">Second</span>
+	  <time itemprop="startDate" datetime="2024-07-01">July 1</time>
+	</div>`
+
+	b, err := ParseData(html, t).ICalendar()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	out := string(b)
+	if strings.Count(out, "SUMMARY") != 1 || !strings.Contains(out, "SUMMARY:A\r\n") {
+		t.Errorf("Expecting a single SUMMARY:A in:\n%s", out)
+	}
+	if strings.Count(out, "DESCRIPTION") != 1 || !strings.Contains(out, "DESCRIPTION:First\r\n") {
+		t.Errorf("Expecting a single DESCRIPTION:First in:\n%s", out)
+	}
+}
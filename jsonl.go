@@ -0,0 +1,135 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Envelope describes the document that items were extracted from. Fields
+// with zero values are not written.
+type Envelope struct {
+	Source  string    // URL of the document
+	Fetched time.Time // time the document was fetched
+	Hash    string    // hash of the document content, in a form chosen by the caller
+}
+
+func (e Envelope) empty() bool {
+	return e.Source == "" && e.Fetched.IsZero() && e.Hash == ""
+}
+
+// Encoder writes items as JSON Lines: one JSON object per line for each
+// top-level item, so that output for many documents can be concatenated and
+// processed line by line.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes a line for each top-level item of m. Items are written in the
+// form of Microdata.OrderedJSON. When env has any fields set each line is
+// instead an object with source, fetched and hash fields as given by env and
+// an item field holding the item. An item nested within itself cannot be
+// written, so Encode stops with an error before writing its line.
+func (e *Encoder) Encode(m *Microdata, env Envelope) error {
+	var buf bytes.Buffer
+	for _, item := range m.Items {
+		buf.Reset()
+		if err := writeEnvelopedItem(&buf, item, env); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := e.w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEnvelopedItem(buf *bytes.Buffer, item *Item, env Envelope) error {
+	if env.empty() {
+		return writeOrderedItem(buf, item, make(map[*Item]bool))
+	}
+
+	buf.WriteByte('{')
+	if env.Source != "" {
+		buf.WriteString(`"source":`)
+		if err := writeJSONValue(buf, env.Source); err != nil {
+			return err
+		}
+		buf.WriteByte(',')
+	}
+	if !env.Fetched.IsZero() {
+		buf.WriteString(`"fetched":`)
+		if err := writeJSONValue(buf, env.Fetched.Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+		buf.WriteByte(',')
+	}
+	if env.Hash != "" {
+		buf.WriteString(`"hash":`)
+		if err := writeJSONValue(buf, env.Hash); err != nil {
+			return err
+		}
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"item":`)
+	if err := writeOrderedItem(buf, item, make(map[*Item]bool)); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// Decoder reads items written by an Encoder.
+type Decoder struct {
+	dec *json.Decoder
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// Decode reads the next item and its envelope, which is empty if the item
+// was written without one. It returns io.EOF when there are no more items.
+func (d *Decoder) Decode() (*Item, Envelope, error) {
+	var line json.RawMessage
+	if err := d.dec.Decode(&line); err != nil {
+		return nil, Envelope{}, err
+	}
+
+	var envelope struct {
+		Source  string          `json:"source"`
+		Fetched time.Time       `json:"fetched"`
+		Hash    string          `json:"hash"`
+		Item    json.RawMessage `json:"item"`
+	}
+	if err := json.Unmarshal(line, &envelope); err != nil {
+		return nil, Envelope{}, err
+	}
+
+	item := NewItem()
+	if envelope.Item == nil {
+		// the line is the item itself
+		if err := json.Unmarshal(line, item); err != nil {
+			return nil, Envelope{}, err
+		}
+		return item, Envelope{}, nil
+	}
+
+	if err := json.Unmarshal(envelope.Item, item); err != nil {
+		return nil, Envelope{}, err
+	}
+	return item, Envelope{Source: envelope.Source, Fetched: envelope.Fetched, Hash: envelope.Hash}, nil
+}
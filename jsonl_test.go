@@ -0,0 +1,102 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoder(t *testing.T) {
+	data := ParseData(`<div itemscope itemtype="http://schema.org/Thing" itemid="http://example.com/a">
+	  <span itemprop="name">A</span><span itemprop="alternateName">Alpha</span>
+	</div>
+	<div itemscope><span itemprop="name">B</span></div>`, t)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode(data, Envelope{}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	fetched := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := enc.Encode(data, Envelope{Source: "http://example.com/page", Fetched: fetched, Hash: "sha256:abc"}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `{"properties":{"name":["A"],"alternateName":["Alpha"]},"type":["http://schema.org/Thing"],"id":"http://example.com/a"}
+{"properties":{"name":["B"]}}
+{"source":"http://example.com/page","fetched":"2020-01-02T03:04:05Z","hash":"sha256:abc","item":{"properties":{"name":["A"],"alternateName":["Alpha"]},"type":["http://schema.org/Thing"],"id":"http://example.com/a"}}
+{"source":"http://example.com/page","fetched":"2020-01-02T03:04:05Z","hash":"sha256:abc","item":{"properties":{"name":["B"]}}}
+`
+	if buf.String() != expected {
+		t.Errorf("Expecting %s but got %s", expected, buf.String())
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	data := ParseData(`<div itemscope itemtype="http://schema.org/Product">
+	  <span itemprop="name">Widget</span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer"><span itemprop="price">1</span></div>
+	  <span itemprop="color">red</span>
+	</div>
+	<div itemscope><span itemprop="name">B</span></div>`, t)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	env := Envelope{Source: "http://example.com/page", Fetched: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)}
+	if err := enc.Encode(data, env); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := enc.Encode(data, Envelope{}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	dec := NewDecoder(&buf)
+	for i := 0; i < 4; i++ {
+		item, gotEnv, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("Expected no error decoding item %d but got %v", i, err)
+		}
+		if !item.Equal(data.Items[i%2]) {
+			t.Errorf("Expecting item %d to round trip but got %v", i, item)
+		}
+		expectedEnv := env
+		if i >= 2 {
+			expectedEnv = Envelope{}
+		}
+		if gotEnv.Source != expectedEnv.Source || !gotEnv.Fetched.Equal(expectedEnv.Fetched) || gotEnv.Hash != expectedEnv.Hash {
+			t.Errorf("Expecting envelope %v for item %d but got %v", expectedEnv, i, gotEnv)
+		}
+		if i == 0 && strings.Join(item.PropertyNames(), ",") != "name,offers,color" {
+			t.Errorf("Expecting property order to be kept but got %v", item.PropertyNames())
+		}
+	}
+
+	if _, _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Expecting io.EOF but got %v", err)
+	}
+}
+
+func TestEncoderCycle(t *testing.T) {
+	data, err := ParseJSONLD([]byte(`{"@context": "http://schema.org/", "@graph": [
+	  {"@id": "http://example.com/a", "@type": "Person", "name": "A", "knows": {"@id": "http://example.com/b"}},
+	  {"@id": "http://example.com/b", "@type": "Person", "name": "B", "knows": {"@id": "http://example.com/a"}}
+	]}`), nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(data, Envelope{Source: "http://example.com/page"}); err == nil {
+		t.Errorf("Expecting an error for items that know each other")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expecting no partial line to be written but got %s", buf.String())
+	}
+}
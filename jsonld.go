@@ -0,0 +1,673 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// JSONLDError reports a JSON-LD script block that could not be read.
+type JSONLDError struct {
+	Block int // position of the script block in the document, starting at 0
+	Err   error
+}
+
+func (e *JSONLDError) Error() string {
+	return fmt.Sprintf("microdata: JSON-LD block %d: %v", e.Block, e.Err)
+}
+
+func (e *JSONLDError) Unwrap() error {
+	return e.Err
+}
+
+// JSONLDParser is an HTML parser that extracts JSON-LD script blocks as
+// microdata items
+type JSONLDParser struct {
+	r        io.Reader
+	data     *Microdata
+	base     *url.URL
+	warnings []error
+}
+
+// NewJSONLDParser creates a new parser for extracting JSON-LD
+// r is a reader over an HTML document
+// base is the base URL for resolving relative URLs
+func NewJSONLDParser(r io.Reader, base *url.URL) *JSONLDParser {
+	return &JSONLDParser{
+		r:    r,
+		data: NewMicrodata(),
+		base: base,
+	}
+}
+
+// Parse the document and return a Microdata set holding the nodes of every
+// application/ld+json script block. Blocks that are not valid JSON, or that
+// hold anything after the first JSON value, are skipped and reported by
+// Warnings.
+func (p *JSONLDParser) Parse() (*Microdata, error) {
+	tree, err := html.Parse(p.r)
+	if err != nil {
+		return nil, err
+	}
+	p.parseTree(tree)
+	return p.data, nil
+}
+
+// Warnings returns a JSONLDError for each script block that could not be read
+// by the last call to Parse.
+func (p *JSONLDParser) Warnings() []error {
+	return p.warnings
+}
+
+func (p *JSONLDParser) parseTree(tree *html.Node) {
+	block := 0
+	walk(tree, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Script {
+			return
+		}
+		scriptType, _ := getAttr("type", n)
+		if mediaType := strings.SplitN(scriptType, ";", 2)[0]; !strings.EqualFold(strings.TrimSpace(mediaType), "application/ld+json") {
+			return
+		}
+
+		var text bytes.Buffer
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.TextNode {
+				text.WriteString(child.Data)
+			}
+		}
+
+		items, err := convertJSONLD(text.Bytes(), p.base)
+		if err != nil {
+			p.warnings = append(p.warnings, &JSONLDError{Block: block, Err: err})
+		}
+		for _, item := range items {
+			p.data.AddItem(item)
+		}
+		block++
+	})
+}
+
+// ParseJSONLD converts a JSON-LD document into a Microdata set. Node objects
+// become items, with @type and @id giving the item's types and ID. Terms are
+// expanded using the document's schema.org @context: types become absolute
+// URLs and properties in the context's vocabulary keep their short names, as
+// they would in microdata. Nodes that refer to another node by @id alone are
+// linked to that node's item. base is used to resolve relative IDs.
+func ParseJSONLD(data []byte, base *url.URL) (*Microdata, error) {
+	items, err := convertJSONLD(data, base)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMicrodata()
+	for _, item := range items {
+		m.AddItem(item)
+	}
+	return m, nil
+}
+
+// jsonObject is a decoded JSON object that remembers the order of its keys.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeOrdered decodes a JSON value, keeping object key order. Objects are
+// decoded as *jsonObject and numbers as json.Number.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &jsonObject{values: make(map[string]interface{})}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := obj.values[key]; !exists {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = value
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case json.Delim('['):
+		list := make([]interface{}, 0)
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return tok, nil
+}
+
+// vocabContext holds the vocabulary and prefix mappings used for expanding
+// terms, as given by a JSON-LD @context or by RDFa vocab and prefix attributes.
+type vocabContext struct {
+	vocab    string
+	prefixes map[string]string
+}
+
+// schemaContexts are the context references that mean the schema.org
+// vocabulary.
+var schemaContexts = map[string]string{
+	"http://schema.org":                           "http://schema.org/",
+	"http://schema.org/":                          "http://schema.org/",
+	"https://schema.org":                          "https://schema.org/",
+	"https://schema.org/":                         "https://schema.org/",
+	"http://schema.org/docs/jsonldcontext.json":   "http://schema.org/",
+	"https://schema.org/docs/jsonldcontext.json":  "https://schema.org/",
+	"http://www.schema.org":                       "http://schema.org/",
+	"https://www.schema.org":                      "https://schema.org/",
+	"http://schema.org/docs/jsonldcontext.jsonld": "http://schema.org/",
+}
+
+// with returns a copy of the context updated by a @context value.
+func (c *vocabContext) with(value interface{}) *vocabContext {
+	nc := &vocabContext{vocab: c.vocab, prefixes: make(map[string]string, len(c.prefixes))}
+	for k, v := range c.prefixes {
+		nc.prefixes[k] = v
+	}
+
+	var apply func(v interface{})
+	apply = func(v interface{}) {
+		switch tv := v.(type) {
+		case string:
+			if vocab, exists := schemaContexts[strings.TrimSpace(tv)]; exists {
+				nc.vocab = vocab
+			}
+		case []interface{}:
+			for _, e := range tv {
+				apply(e)
+			}
+		case *jsonObject:
+			for _, key := range tv.keys {
+				switch def := tv.values[key].(type) {
+				case string:
+					if key == "@vocab" {
+						nc.vocab = def
+					} else if !strings.HasPrefix(key, "@") {
+						nc.prefixes[key] = def
+					}
+				case *jsonObject:
+					if id, ok := def.values["@id"].(string); ok {
+						nc.prefixes[key] = id
+					}
+				}
+			}
+		case nil:
+			nc.vocab = ""
+			nc.prefixes = make(map[string]string)
+		}
+	}
+	apply(value)
+	return nc
+}
+
+// expand expands a compact IRI or term into an absolute IRI where possible.
+func (c *vocabContext) expand(term string) string {
+	if iri, exists := c.prefixes[term]; exists {
+		term = iri
+	}
+	if i := strings.Index(term, ":"); i > 0 {
+		if iri, exists := c.prefixes[term[:i]]; exists && !strings.HasPrefix(term[i+1:], "//") {
+			return iri + term[i+1:]
+		}
+		return term
+	}
+	if c.vocab != "" {
+		return c.vocab + term
+	}
+	return term
+}
+
+// property returns the microdata property name for a term: terms in the
+// context's vocabulary keep their short names and others are expanded.
+func (c *vocabContext) property(key string) string {
+	iri := c.expand(key)
+	if c.vocab != "" && strings.HasPrefix(iri, c.vocab) && len(iri) > len(c.vocab) {
+		return iri[len(c.vocab):]
+	}
+	return iri
+}
+
+type jsonldConverter struct {
+	base  *url.URL
+	nodes map[string]*Item
+	refs  []pendingRef
+}
+
+// pendingRef is a property value that refers to another node by its
+// identifier.
+type pendingRef struct {
+	item  *Item
+	name  string
+	index int
+	id    string
+}
+
+// linkRefs replaces each referring value with the item having that
+// identifier, if the document defines one.
+func linkRefs(refs []pendingRef, nodes map[string]*Item) {
+	for _, ref := range refs {
+		if target, exists := nodes[ref.id]; exists {
+			ref.item.Properties[ref.name][ref.index] = target
+		}
+	}
+}
+
+// decodeJSONLD decodes a JSON-LD document, which must be a single JSON value.
+func decodeJSONLD(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("microdata: unexpected content after JSON-LD document")
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+func convertJSONLD(data []byte, base *url.URL) ([]*Item, error) {
+	doc, err := decodeJSONLD(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &jsonldConverter{base: base, nodes: make(map[string]*Item)}
+	items := c.topLevel(doc, &vocabContext{prefixes: make(map[string]string)})
+
+	linkRefs(c.refs, c.nodes)
+	return items, nil
+}
+
+func (c *jsonldConverter) topLevel(doc interface{}, ctx *vocabContext) []*Item {
+	items := make([]*Item, 0)
+	switch tv := doc.(type) {
+	case []interface{}:
+		for _, e := range tv {
+			items = append(items, c.topLevel(e, ctx)...)
+		}
+	case *jsonObject:
+		if value, exists := tv.values["@context"]; exists {
+			ctx = ctx.with(value)
+		}
+		if graph, exists := tv.values["@graph"]; exists {
+			items = append(items, c.topLevel(graph, ctx)...)
+			if !hasNodeProperties(tv) {
+				break
+			}
+		}
+		items = append(items, c.node(tv, ctx))
+	}
+	return items
+}
+
+// hasNodeProperties reports whether an object has keys other than @context,
+// @graph and @id, and so describes a node itself.
+func hasNodeProperties(obj *jsonObject) bool {
+	for _, key := range obj.keys {
+		if key != "@context" && key != "@graph" && key != "@id" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *jsonldConverter) node(obj *jsonObject, ctx *vocabContext) *Item {
+	if value, exists := obj.values["@context"]; exists {
+		ctx = ctx.with(value)
+	}
+
+	item := NewItem()
+	if id, ok := obj.values["@id"].(string); ok {
+		resolved := c.resolveID(id)
+		c.nodes[resolved] = item
+		if !strings.HasPrefix(id, "_:") {
+			item.ID = resolved
+		}
+	}
+
+	switch types := obj.values["@type"].(type) {
+	case string:
+		item.AddType(ctx.expand(types))
+	case []interface{}:
+		for _, t := range types {
+			if s, ok := t.(string); ok {
+				item.AddType(ctx.expand(s))
+			}
+		}
+	}
+
+	for _, key := range obj.keys {
+		if strings.HasPrefix(key, "@") {
+			continue
+		}
+		c.addValues(item, ctx.property(key), obj.values[key], ctx)
+	}
+	return item
+}
+
+func (c *jsonldConverter) addValues(item *Item, name string, value interface{}, ctx *vocabContext) {
+	switch tv := value.(type) {
+	case nil:
+	case string:
+		item.AddString(name, tv)
+	case json.Number:
+		item.AddString(name, tv.String())
+	case bool:
+		item.AddString(name, fmt.Sprint(tv))
+	case []interface{}:
+		for _, e := range tv {
+			c.addValues(item, name, e, ctx)
+		}
+	case *jsonObject:
+		if v, exists := tv.values["@value"]; exists {
+			c.addValues(item, name, v, ctx)
+			return
+		}
+		if list, exists := tv.values["@list"]; exists {
+			c.addValues(item, name, list, ctx)
+			return
+		}
+		if set, exists := tv.values["@set"]; exists {
+			c.addValues(item, name, set, ctx)
+			return
+		}
+		if id, ok := tv.values["@id"].(string); ok && len(tv.keys) == 1 {
+			resolved := c.resolveID(id)
+			item.AddString(name, resolved)
+			c.refs = append(c.refs, pendingRef{item: item, name: name, index: len(item.Properties[name]) - 1, id: resolved})
+			return
+		}
+		item.AddItem(name, c.node(tv, ctx))
+	}
+}
+
+// resolveID resolves a node identifier against the base URL, leaving blank
+// node identifiers unchanged.
+func (c *jsonldConverter) resolveID(id string) string {
+	if strings.HasPrefix(id, "_:") {
+		return id
+	}
+	if resolved, ok := resolveURL(c.base, id); ok {
+		return resolved
+	}
+	return id
+}
+
+// JSONLD writes the graph as a flattened JSON-LD document with a node object
+// for each subject in @graph. Predicates are written as full IRIs, without a
+// context, and blank nodes are given identifiers such as _:b0. The document
+// can be read back into the same graph with ParseGraphJSONLD, or with
+// ParseJSONLD into items whose properties are named by their IRIs.
+func (g *Graph) JSONLD() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"@graph":[`)
+
+	subjects, bySubject := groupBySubject(g.Triples)
+	for idx, s := range subjects {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"@id":`)
+		if err := writeJSONValue(&buf, jsonldID(s)); err != nil {
+			return nil, err
+		}
+
+		types := make([]string, 0)
+		predicates := make([]string, 0)
+		objects := make(map[string][]Term)
+		for _, t := range bySubject[s] {
+			if t.Predicate.Value == rdfType && t.Object.Kind == IRI {
+				types = append(types, t.Object.Value)
+				continue
+			}
+			if _, exists := objects[t.Predicate.Value]; !exists {
+				predicates = append(predicates, t.Predicate.Value)
+			}
+			objects[t.Predicate.Value] = append(objects[t.Predicate.Value], t.Object)
+		}
+
+		if len(types) > 0 {
+			buf.WriteString(`,"@type":`)
+			if err := writeJSONValue(&buf, types); err != nil {
+				return nil, err
+			}
+		}
+		for _, p := range predicates {
+			buf.WriteByte(',')
+			if err := writeJSONValue(&buf, p); err != nil {
+				return nil, err
+			}
+			buf.WriteString(`:[`)
+			for oidx, o := range objects[p] {
+				if oidx > 0 {
+					buf.WriteByte(',')
+				}
+				if err := writeJSONValue(&buf, jsonldObject(o)); err != nil {
+					return nil, err
+				}
+			}
+			buf.WriteByte(']')
+		}
+		buf.WriteByte('}')
+	}
+
+	buf.WriteString(`]}`)
+	return buf.Bytes(), nil
+}
+
+// ParseGraphJSONLD reads a JSON-LD document, such as one written by
+// Graph.JSONLD, into RDF triples so that Graph.Microdata can rebuild items with
+// short property names. Terms are expanded using the document's @context as
+// ParseJSONLD expands them, and keys that do not expand to an absolute IRI
+// are ignored. Nodes with an @id are IRIs, resolved against base, or blank
+// nodes when the @id begins with _:, and other nodes are blank. Blank nodes are
+// labelled b0, b1 and so on in the order they are found. Value objects keep
+// their @language or @type, while strings, numbers and booleans are plain
+// literals. The members of @list and @set values are read as separate objects.
+func ParseGraphJSONLD(data []byte, base *url.URL) (*Graph, error) {
+	doc, err := decodeJSONLD(data)
+	if err != nil {
+		return nil, err
+	}
+	r := &jsonldGraphReader{base: base, labels: make(map[string]Term)}
+	r.topLevel(doc, &vocabContext{prefixes: make(map[string]string)})
+	return &Graph{Triples: r.triples}, nil
+}
+
+// jsonldGraphReader reads JSON-LD into triples as described by
+// ParseGraphJSONLD.
+type jsonldGraphReader struct {
+	base    *url.URL
+	labels  map[string]Term // blank nodes by their label in the document
+	blanks  int
+	triples []Triple
+}
+
+func (r *jsonldGraphReader) topLevel(doc interface{}, ctx *vocabContext) {
+	switch tv := doc.(type) {
+	case []interface{}:
+		for _, e := range tv {
+			r.topLevel(e, ctx)
+		}
+	case *jsonObject:
+		if value, exists := tv.values["@context"]; exists {
+			ctx = ctx.with(value)
+		}
+		if graph, exists := tv.values["@graph"]; exists {
+			r.topLevel(graph, ctx)
+			if !hasNodeProperties(tv) {
+				break
+			}
+		}
+		r.node(r.subject(tv), tv, ctx)
+	}
+}
+
+// subject returns the term identifying a node object.
+func (r *jsonldGraphReader) subject(obj *jsonObject) Term {
+	id, ok := obj.values["@id"].(string)
+	if !ok {
+		return r.blank("")
+	}
+	if strings.HasPrefix(id, "_:") {
+		return r.blank(id[2:])
+	}
+	if resolved, ok := resolveURL(r.base, id); ok {
+		id = resolved
+	}
+	return Term{Kind: IRI, Value: id}
+}
+
+// blank returns the blank node with a label in the document, or a new one if
+// label is empty.
+func (r *jsonldGraphReader) blank(label string) Term {
+	if t, exists := r.labels[label]; exists && label != "" {
+		return t
+	}
+	t := Term{Kind: Blank, Value: fmt.Sprintf("b%d", r.blanks)}
+	r.blanks++
+	if label != "" {
+		r.labels[label] = t
+	}
+	return t
+}
+
+// node adds the triples of a node object about subject.
+func (r *jsonldGraphReader) node(subject Term, obj *jsonObject, ctx *vocabContext) {
+	if value, exists := obj.values["@context"]; exists {
+		ctx = ctx.with(value)
+	}
+
+	var types []interface{}
+	switch tv := obj.values["@type"].(type) {
+	case string:
+		types = []interface{}{tv}
+	case []interface{}:
+		types = tv
+	}
+	for _, t := range types {
+		if s, ok := t.(string); ok {
+			r.triples = append(r.triples, Triple{subject, Term{Kind: IRI, Value: rdfType}, Term{Kind: IRI, Value: ctx.expand(s)}})
+		}
+	}
+
+	for _, key := range obj.keys {
+		if strings.HasPrefix(key, "@") {
+			continue
+		}
+		if predicate := ctx.expand(key); isAbsoluteIRI(predicate) {
+			r.values(subject, Term{Kind: IRI, Value: predicate}, obj.values[key], ctx)
+		}
+	}
+}
+
+// values adds a triple for each object in a JSON-LD property value.
+func (r *jsonldGraphReader) values(subject, predicate Term, value interface{}, ctx *vocabContext) {
+	switch tv := value.(type) {
+	case []interface{}:
+		for _, e := range tv {
+			r.values(subject, predicate, e, ctx)
+		}
+	case *jsonObject:
+		if v, exists := tv.values["@value"]; exists {
+			object, ok := jsonldLiteral(v)
+			if !ok {
+				return
+			}
+			if language, ok := tv.values["@language"].(string); ok {
+				object.Language = language
+			} else if datatype, ok := tv.values["@type"].(string); ok {
+				object.Datatype = ctx.expand(datatype)
+			}
+			r.triples = append(r.triples, Triple{subject, predicate, object})
+			return
+		}
+		if list, exists := tv.values["@list"]; exists {
+			r.values(subject, predicate, list, ctx)
+			return
+		}
+		if set, exists := tv.values["@set"]; exists {
+			r.values(subject, predicate, set, ctx)
+			return
+		}
+		object := r.subject(tv)
+		r.triples = append(r.triples, Triple{subject, predicate, object})
+		r.node(object, tv, ctx)
+	default:
+		if object, ok := jsonldLiteral(tv); ok {
+			r.triples = append(r.triples, Triple{subject, predicate, object})
+		}
+	}
+}
+
+// jsonldLiteral returns a plain literal for a JSON string, number or boolean.
+func jsonldLiteral(v interface{}) (Term, bool) {
+	switch tv := v.(type) {
+	case string:
+		return Term{Kind: Literal, Value: tv}, true
+	case json.Number:
+		return Term{Kind: Literal, Value: tv.String()}, true
+	case bool:
+		return Term{Kind: Literal, Value: fmt.Sprint(tv)}, true
+	}
+	return Term{}, false
+}
+
+// jsonldID returns the JSON-LD identifier of an IRI or blank node.
+func jsonldID(t Term) string {
+	if t.Kind == Blank {
+		return "_:" + t.Value
+	}
+	return t.Value
+}
+
+// jsonldObject returns the JSON-LD value or node reference object for a term.
+func jsonldObject(t Term) interface{} {
+	if t.Kind != Literal {
+		return map[string]string{"@id": jsonldID(t)}
+	}
+	obj := map[string]string{"@value": t.Value}
+	if t.Language != "" {
+		obj["@language"] = t.Language
+	} else if t.Datatype != "" {
+		obj["@type"] = t.Datatype
+	}
+	return obj
+}
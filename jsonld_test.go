@@ -0,0 +1,273 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ParseJSONLDData(html string, t *testing.T) (*Microdata, []error) {
+	u, _ := url.Parse("http://example.com/")
+	p := NewJSONLDParser(strings.NewReader(html), u)
+	data, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return data, p.Warnings()
+}
+
+func TestJSONLDNode(t *testing.T) {
+	html := `<html><head><script type="application/ld+json">
+	{
+	  "@context": "https://schema.org",
+	  "@type": "Product",
+	  "@id": "/products/1",
+	  "name": "Widget",
+	  "offers": {"@type": "Offer", "price": 12.5, "priceCurrency": "USD"},
+	  "isFamilyFriendly": true
+	}
+	</script></head></html>`
+
+	data, warnings := ParseJSONLDData(html, t)
+	if len(warnings) != 0 {
+		t.Fatalf("Expecting no warnings but got %v", warnings)
+	}
+	if len(data.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(data.Items))
+	}
+
+	item := data.Items[0]
+	if item.Types[0] != "https://schema.org/Product" {
+		t.Errorf("Expecting type https://schema.org/Product but got %s", item.Types[0])
+	}
+	if item.ID != "http://example.com/products/1" {
+		t.Errorf("Expecting id http://example.com/products/1 but got %s", item.ID)
+	}
+	if item.Properties["name"][0].(string) != "Widget" {
+		t.Errorf("Expecting name Widget but got %v", item.Properties["name"][0])
+	}
+	if item.Properties["isFamilyFriendly"][0].(string) != "true" {
+		t.Errorf("Expecting isFamilyFriendly true but got %v", item.Properties["isFamilyFriendly"][0])
+	}
+
+	offer := item.Properties["offers"][0].(*Item)
+	if offer.Types[0] != "https://schema.org/Offer" {
+		t.Errorf("Expecting type https://schema.org/Offer but got %s", offer.Types[0])
+	}
+	if offer.Properties["price"][0].(string) != "12.5" {
+		t.Errorf("Expecting price 12.5 but got %v", offer.Properties["price"][0])
+	}
+	if names := strings.Join(item.PropertyNames(), ","); names != "name,offers,isFamilyFriendly" {
+		t.Errorf("Expecting properties name,offers,isFamilyFriendly but got %s", names)
+	}
+}
+
+func TestJSONLDGraph(t *testing.T) {
+	html := `<script type="application/ld+json">
+	{
+	  "@context": {"@vocab": "http://schema.org/", "s": "http://schema.org/"},
+	  "@graph": [
+	    {"@id": "#org", "@type": "Organization", "s:name": "Acme"},
+	    {"@id": "_:p1", "@type": "s:Person", "name": {"@value": "Jane"}, "worksFor": {"@id": "#org"}},
+	    {"@type": "Article", "headline": "News", "author": {"@id": "_:p1"}, "publisher": {"@id": "#org"}, "sameAs": {"@id": "http://other.example.com/"}}
+	  ]
+	}
+	</script>`
+
+	data, _ := ParseJSONLDData(html, t)
+	if len(data.Items) != 3 {
+		t.Fatalf("Expecting 3 items but got %d", len(data.Items))
+	}
+
+	org, person, article := data.Items[0], data.Items[1], data.Items[2]
+	if org.ID != "http://example.com/#org" || org.Properties["name"][0].(string) != "Acme" {
+		t.Errorf("Expecting organization http://example.com/#org named Acme but got %s %v", org.ID, org.Properties)
+	}
+	if person.ID != "" {
+		t.Errorf("Expecting blank node to have no id but got %s", person.ID)
+	}
+	if person.Types[0] != "http://schema.org/Person" {
+		t.Errorf("Expecting type http://schema.org/Person but got %s", person.Types[0])
+	}
+	if person.Properties["name"][0].(string) != "Jane" {
+		t.Errorf("Expecting name Jane but got %v", person.Properties["name"][0])
+	}
+	if person.Properties["worksFor"][0] != org {
+		t.Errorf("Expecting worksFor to be the organization item but got %v", person.Properties["worksFor"][0])
+	}
+	if article.Properties["author"][0] != person {
+		t.Errorf("Expecting author to be the person item but got %v", article.Properties["author"][0])
+	}
+	if article.Properties["publisher"][0] != org {
+		t.Errorf("Expecting publisher to be the organization item but got %v", article.Properties["publisher"][0])
+	}
+	if article.Properties["sameAs"][0].(string) != "http://other.example.com/" {
+		t.Errorf("Expecting unresolved reference to be kept as a URL but got %v", article.Properties["sameAs"][0])
+	}
+}
+
+func TestJSONLDMalformedBlock(t *testing.T) {
+	html := `<script type="application/ld+json">{"@type": "Thing", </script>
+	<script type="text/javascript">var x = 1;</script>
+	<script type="application/ld+json">[{"@context": "http://schema.org", "@type": "Event", "name": "Party"}]</script>`
+
+	data, warnings := ParseJSONLDData(html, t)
+	if len(warnings) != 1 {
+		t.Fatalf("Expecting 1 warning but got %d", len(warnings))
+	}
+	var jsonldErr *JSONLDError
+	if !errors.As(warnings[0], &jsonldErr) || jsonldErr.Block != 0 {
+		t.Errorf("Expecting a JSONLDError for block 0 but got %v", warnings[0])
+	}
+
+	if len(data.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(data.Items))
+	}
+	if data.Items[0].Types[0] != "http://schema.org/Event" {
+		t.Errorf("Expecting type http://schema.org/Event but got %s", data.Items[0].Types[0])
+	}
+}
+
+func TestJSONLDTrailingContent(t *testing.T) {
+	html := `<script type="application/ld+json">{"@type": "Thing", "name": "A"} {"@type": "Thing", "name": "B"}</script>
+	<script type="application/ld+json">{"@type": "Thing", "name": "C"} garbage</script>
+	<script type="application/ld+json">{"@type": "Thing", "name": "D"}
+	</script>`
+
+	data, warnings := ParseJSONLDData(html, t)
+	if len(warnings) != 2 {
+		t.Fatalf("Expecting 2 warnings but got %v", warnings)
+	}
+	var jsonldErr *JSONLDError
+	if !errors.As(warnings[1], &jsonldErr) || jsonldErr.Block != 1 {
+		t.Errorf("Expecting a JSONLDError for block 1 but got %v", warnings[1])
+	}
+	if len(data.Items) != 1 || data.Items[0].Properties["name"][0] != "D" {
+		t.Errorf("Expecting only the item of the last block but got %v", data.Items)
+	}
+}
+
+func TestJSONLDTermAliases(t *testing.T) {
+	// A term is replaced by its definition once, so definitions that refer to
+	// themselves or to each other do not loop.
+	data, err := ParseJSONLD([]byte(`{"@context": {"@vocab": "http://schema.org/", "title": "headline", "name": "name", "a": "b", "b": "a", "s": "http://schema.org/"}, "@type": "Article", "title": "T", "name": "N", "a": "A", "s:author": "W"}`), nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := map[string]string{"headline": "T", "name": "N", "b": "A", "author": "W"}
+	item := data.Items[0]
+	for name, value := range expected {
+		if values := item.Properties[name]; len(values) != 1 || values[0] != value {
+			t.Errorf("Expecting %s to be %q but got %v", name, value, item.Properties)
+		}
+	}
+}
+
+func TestParseJSONLD(t *testing.T) {
+	data, err := ParseJSONLD([]byte(`{"@context": "http://schema.org/", "@type": ["Book", "Product"], "name": "Go", "author": [{"@type": "Person", "name": "A"}, {"@type": "Person", "name": "B"}]}`), nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	item := data.Items[0]
+	if len(item.Types) != 2 || item.Types[1] != "http://schema.org/Product" {
+		t.Errorf("Expecting types Book and Product but got %v", item.Types)
+	}
+	if len(item.Properties["author"]) != 2 {
+		t.Errorf("Expecting 2 authors but got %d", len(item.Properties["author"]))
+	}
+
+	if _, err := ParseJSONLD([]byte(`{`), nil); err == nil {
+		t.Errorf("Expecting an error for malformed JSON")
+	}
+}
+
+func TestGraphJSONLD(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+	  <span itemprop="name">Widget</span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer"><span itemprop="price">12.50</span></div>
+	</div>`
+
+	original := ParseData(html, t)
+	b, err := original.Graph().JSONLD()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `{"@graph":[{"@id":"http://example.com/widget","@type":["http://schema.org/Product"],"http://schema.org/name":[{"@value":"Widget"}],"http://schema.org/offers":[{"@id":"_:b0"}]},{"@id":"_:b0","@type":["http://schema.org/Offer"],"http://schema.org/price":[{"@value":"12.50"}]}]}`
+	if string(b) != expected {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+
+	parsed, err := ParseJSONLD(b, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !reflect.DeepEqual(parsed.Graph().Triples, original.Graph().Triples) {
+		t.Errorf("Expecting the same triples after reading the JSON-LD but got %v", parsed.Graph().Triples)
+	}
+}
+
+func TestParseGraphJSONLD(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+	  <span itemprop="name">Widget</span>
+	  <link itemprop="url" href="http://example.com/widget.html">
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer"><span itemprop="price">12.50</span></div>
+	</div>`
+
+	original := ParseData(html, t)
+	original.Items[0].AddItem("description", literalItem(Term{Kind: Literal, Value: "Ein Ding", Language: "de"}))
+	b, err := original.Graph().JSONLD()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	g, err := ParseGraphJSONLD(b, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if again, err := g.JSONLD(); err != nil || string(again) != string(b) {
+		t.Errorf("Expecting the same graph after reading the JSON-LD but got %s", again)
+	}
+
+	rebuilt := g.Microdata()
+	if len(rebuilt.Items) != 1 || !rebuilt.Items[0].Equal(original.Items[0]) {
+		t.Errorf("Expecting the items to be rebuilt with short property names but got %v", rebuilt.Items)
+	}
+
+	if _, err := ParseGraphJSONLD([]byte(`{`), nil); err == nil {
+		t.Errorf("Expecting an error for malformed JSON")
+	}
+}
+
+func TestParseGraphJSONLDContext(t *testing.T) {
+	doc := []byte(`{"@context": "https://schema.org", "@id": "/jane", "@type": "Person",
+	  "name": {"@value": "Jane", "@language": "en"}, "age": 30, "knows": {"@id": "_:x", "name": "Bob"}, "colleague": {"@id": "_:x"}}`)
+
+	base, _ := url.Parse("http://example.com/")
+	g, err := ParseGraphJSONLD(doc, base)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	jane := Term{Kind: IRI, Value: "http://example.com/jane"}
+	bob := Term{Kind: Blank, Value: "b0"}
+	expected := []Triple{
+		{jane, Term{Kind: IRI, Value: rdfType}, Term{Kind: IRI, Value: "https://schema.org/Person"}},
+		{jane, Term{Kind: IRI, Value: "https://schema.org/name"}, Term{Kind: Literal, Value: "Jane", Language: "en"}},
+		{jane, Term{Kind: IRI, Value: "https://schema.org/age"}, Term{Kind: Literal, Value: "30"}},
+		{jane, Term{Kind: IRI, Value: "https://schema.org/knows"}, bob},
+		{bob, Term{Kind: IRI, Value: "https://schema.org/name"}, Term{Kind: Literal, Value: "Bob"}},
+		{jane, Term{Kind: IRI, Value: "https://schema.org/colleague"}, bob},
+	}
+	if !reflect.DeepEqual(g.Triples, expected) {
+		t.Errorf("Expecting %v but got %v", expected, g.Triples)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// HTML renders the items as a hidden HTML fragment of microdata markup that
+// parses back into the same items, except that the IDs of items without types
+// are lost since microdata only allows itemid alongside itemtype. Each item is
+// a div with itemscope, itemtype and itemid attributes. String values that are absolute URLs are
+// written as link elements and other strings as meta elements. Nested items
+// are written in place each time they occur; an item nested within itself is
+// written as a link to its ID, or left out if it has none.
+func (m *Microdata) HTML() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("<div style=\"display:none\">\n")
+	for _, item := range m.Items {
+		writeItemMarkup(&buf, item, "", 1, map[*Item]bool{})
+	}
+	buf.WriteString("</div>\n")
+	return buf.Bytes(), nil
+}
+
+// JSONLDToHTML converts a JSON-LD document into an equivalent hidden fragment
+// of microdata markup. See ParseJSONLD for how the document is read and
+// Microdata.HTML for how it is written. The @id of a node without an @type is
+// not kept.
+func JSONLDToHTML(data []byte, base *url.URL) ([]byte, error) {
+	m, err := ParseJSONLD(data, base)
+	if err != nil {
+		return nil, err
+	}
+	return m.HTML()
+}
+
+func writeItemMarkup(buf *bytes.Buffer, item *Item, property string, depth int, ancestors map[*Item]bool) {
+	ancestors[item] = true
+	defer delete(ancestors, item)
+
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString(indent)
+	buf.WriteString("<div")
+	if property != "" {
+		writeAttr(buf, "itemprop", property)
+	}
+	buf.WriteString(" itemscope")
+	if len(item.Types) > 0 {
+		writeAttr(buf, "itemtype", strings.Join(item.Types, " "))
+		if item.ID != "" {
+			writeAttr(buf, "itemid", item.ID)
+		}
+	}
+	buf.WriteString(">\n")
+
+	for _, name := range item.PropertyNames() {
+		for _, v := range item.Properties[name] {
+			switch tv := v.(type) {
+			case string:
+				buf.WriteString(indent + "  ")
+				if isAbsoluteURL(tv) {
+					buf.WriteString("<link")
+					writeAttr(buf, "itemprop", name)
+					writeAttr(buf, "href", tv)
+				} else {
+					buf.WriteString("<meta")
+					writeAttr(buf, "itemprop", name)
+					writeAttr(buf, "content", tv)
+				}
+				buf.WriteString(">\n")
+			case *Item:
+				if !ancestors[tv] {
+					writeItemMarkup(buf, tv, name, depth+1, ancestors)
+				} else if tv.ID != "" {
+					buf.WriteString(indent + "  <link")
+					writeAttr(buf, "itemprop", name)
+					writeAttr(buf, "href", tv.ID)
+					buf.WriteString(">\n")
+				}
+			}
+		}
+	}
+
+	buf.WriteString(indent)
+	buf.WriteString("</div>\n")
+}
+
+func writeAttr(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(" ")
+	buf.WriteString(name)
+	buf.WriteString("=\"")
+	buf.WriteString(html.EscapeString(value))
+	buf.WriteString("\"")
+}
+
+// isAbsoluteURL reports whether s is an absolute URL that is unchanged by
+// parsing, such as http://schema.org/InStock.
+func isAbsoluteURL(s string) bool {
+	if strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != "" && u.String() == s
+}
@@ -0,0 +1,116 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+)
+
+func TestJSONLDToHTML(t *testing.T) {
+	doc := []byte(`{
+	  "@context": "https://schema.org",
+	  "@type": "Product",
+	  "@id": "http://example.com/widget",
+	  "name": "Widget <Deluxe> & \"Co\"",
+	  "url": "http://example.com/widget.html",
+	  "offers": {"@type": "Offer", "price": 12.5, "availability": "https://schema.org/InStock"}
+	}`)
+
+	b, err := JSONLDToHTML(doc, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []byte(`<div style="display:none">
+  <div itemscope itemtype="https://schema.org/Product" itemid="http://example.com/widget">
+    <meta itemprop="name" content="Widget &lt;Deluxe&gt; &amp; &#34;Co&#34;">
+    <link itemprop="url" href="http://example.com/widget.html">
+    <div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+      <meta itemprop="price" content="12.5">
+      <link itemprop="availability" href="https://schema.org/InStock">
+    </div>
+  </div>
+</div>
+`)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestJSONLDToHTMLRoundTrip(t *testing.T) {
+	doc := []byte(`{
+	  "@context": {"@vocab": "http://schema.org/"},
+	  "@graph": [
+	    {"@id": "http://example.com/#org", "@type": "Organization", "name": "Acme", "sameAs": ["http://acme.example.org/", "acme"]},
+	    {"@type": ["Person", "http://example.org/Author"], "name": "Jane", "worksFor": {"@id": "http://example.com/#org"}, "knows": {"@type": "Person", "name": "Bob"}}
+	  ]
+	}`)
+
+	base, _ := url.Parse("http://example.com/")
+	original, err := ParseJSONLD(doc, base)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	b, err := original.HTML()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	parsed := ParseData(string(b), t)
+	if len(parsed.Items) != len(original.Items) {
+		t.Fatalf("Expecting %d items but got %d", len(original.Items), len(parsed.Items))
+	}
+	for i := range original.Items {
+		if !parsed.Items[i].Equal(original.Items[i]) {
+			t.Errorf("Expecting item %d to round trip but got %s", i, b)
+		}
+	}
+}
+
+func TestJSONLDToHTMLUntypedID(t *testing.T) {
+	doc := []byte(`{"@context": "https://schema.org", "@id": "http://example.com/#thing", "name": "Thing"}`)
+
+	b, err := JSONLDToHTML(doc, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []byte(`<div style="display:none">
+  <div itemscope>
+    <meta itemprop="name" content="Thing">
+  </div>
+</div>
+`)
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestHTMLCycle(t *testing.T) {
+	a, b := NewItem(), NewItem()
+	a.AddType("http://schema.org/Person")
+	a.ID = "http://example.com/a"
+	a.AddString("name", "A")
+	a.AddItem("knows", b)
+	b.AddType("http://schema.org/Person")
+	b.AddString("name", "B")
+	b.AddItem("knows", a)
+
+	data := NewMicrodata()
+	data.AddItem(a)
+	out, err := data.HTML()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	parsed := ParseOneItem(string(out), t)
+	friend := parsed.Properties["knows"][0].(*Item)
+	if friend.Properties["knows"][0].(string) != "http://example.com/a" {
+		t.Errorf("Expecting cycle to be written as a link to http://example.com/a but got %s", out)
+	}
+}
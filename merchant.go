@@ -0,0 +1,268 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// MerchantProduct is a product in a Google Merchant Center feed. Field values
+// use the formats required by the feed specification, such as in_stock for
+// Availability and "15.00 USD" for Price.
+type MerchantProduct struct {
+	ID                   string
+	Title                string
+	Description          string
+	Link                 string
+	ImageLink            string
+	AdditionalImageLinks []string
+	Availability         string
+	Price                string
+	Brand                string
+	GTIN                 string
+	MPN                  string
+	Condition            string
+}
+
+// MerchantProblem reports a Product item that could not be included in a feed
+// because it lacks required feed attributes.
+type MerchantProblem struct {
+	Item    *Item
+	ID      string   // the product's feed id, if it has one
+	Missing []string // names of the missing feed attributes, such as image_link
+}
+
+// MerchantChannel describes a Merchant Center XML feed.
+type MerchantChannel struct {
+	Title       string
+	Link        string
+	Description string
+}
+
+// merchantAvailability maps schema.org ItemAvailability members to feed
+// availability values.
+var merchantAvailability = map[string]string{
+	"InStock":             "in_stock",
+	"InStoreOnly":         "in_stock",
+	"OnlineOnly":          "in_stock",
+	"LimitedAvailability": "in_stock",
+	"OutOfStock":          "out_of_stock",
+	"SoldOut":             "out_of_stock",
+	"Discontinued":        "out_of_stock",
+	"PreOrder":            "preorder",
+	"PreSale":             "preorder",
+	"BackOrder":           "backorder",
+}
+
+// merchantCondition maps schema.org OfferItemCondition members to feed
+// condition values.
+var merchantCondition = map[string]string{
+	"NewCondition":         "new",
+	"UsedCondition":        "used",
+	"DamagedCondition":     "used",
+	"RefurbishedCondition": "refurbished",
+}
+
+// MerchantProducts converts the Product items in the given sets into feed
+// products. Price, availability and condition are taken from the product's
+// first Offer or AggregateOffer. Products missing any of the id, title,
+// description, link, image_link, availability, price or brand attributes are
+// left out and reported as problems.
+func MerchantProducts(sets ...*Microdata) ([]*MerchantProduct, []*MerchantProblem) {
+	products := make([]*MerchantProduct, 0)
+	problems := make([]*MerchantProblem, 0)
+
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		set.Walk(func(v Visit) error {
+			item, ok := v.Value.(*Item)
+			if !ok || v.Shared || !isSchemaType(item, "Product") {
+				return nil
+			}
+
+			p := merchantProduct(item)
+			if missing := p.missing(); len(missing) > 0 {
+				problems = append(problems, &MerchantProblem{Item: item, ID: p.ID, Missing: missing})
+				return nil
+			}
+			products = append(products, p)
+			return nil
+		})
+	}
+	return products, problems
+}
+
+func merchantProduct(item *Item) *MerchantProduct {
+	offer := itemValue(item, "offers")
+	if offer == nil {
+		offer = NewItem()
+	}
+
+	p := &MerchantProduct{
+		Title:       cleanText(stringValue(item, "name")),
+		Description: cleanText(stringValue(item, "description")),
+		Link:        strings.TrimSpace(stringValue(item, "url")),
+		Brand:       cleanText(textValue(firstValue(item, "brand"))),
+		GTIN:        cleanText(stringValue(item, "gtin", "gtin14", "gtin13", "gtin12", "gtin8")),
+		MPN:         cleanText(stringValue(item, "mpn")),
+	}
+	setIfEmpty(&p.GTIN, cleanText(stringValue(offer, "gtin", "gtin14", "gtin13", "gtin12", "gtin8")))
+	setIfEmpty(&p.MPN, cleanText(stringValue(offer, "mpn")))
+	setIfEmpty(&p.Link, strings.TrimSpace(stringValue(offer, "url")))
+
+	p.ID = cleanText(stringValue(item, "sku", "productID"))
+	setIfEmpty(&p.ID, cleanText(stringValue(offer, "sku")))
+	setIfEmpty(&p.ID, p.MPN)
+	setIfEmpty(&p.ID, p.GTIN)
+	setIfEmpty(&p.ID, item.ID)
+
+	for _, v := range item.Properties["image"] {
+		link := strings.TrimSpace(urlValue(v))
+		if link == "" {
+			continue
+		}
+		if p.ImageLink == "" {
+			p.ImageLink = link
+		} else {
+			p.AdditionalImageLinks = append(p.AdditionalImageLinks, link)
+		}
+	}
+
+	price := cleanText(stringValue(offer, "price", "lowPrice"))
+	currency := cleanText(stringValue(offer, "priceCurrency"))
+	if price != "" && currency != "" {
+		p.Price = price + " " + currency
+	}
+
+	p.Availability = merchantAvailability[enumValue(stringValue(offer, "availability"))]
+	p.Condition = merchantCondition[enumValue(stringValue(offer, "itemCondition"))]
+	setIfEmpty(&p.Condition, merchantCondition[enumValue(stringValue(item, "itemCondition"))])
+
+	return p
+}
+
+func (p *MerchantProduct) missing() []string {
+	missing := make([]string, 0)
+	for _, attr := range []struct{ name, value string }{
+		{"id", p.ID},
+		{"title", p.Title},
+		{"description", p.Description},
+		{"link", p.Link},
+		{"image_link", p.ImageLink},
+		{"availability", p.Availability},
+		{"price", p.Price},
+		{"brand", p.Brand},
+	} {
+		if attr.value == "" {
+			missing = append(missing, attr.name)
+		}
+	}
+	return missing
+}
+
+// identifierExists returns "no" for products with neither a GTIN nor an MPN,
+// as the feed requires.
+func (p *MerchantProduct) identifierExists() string {
+	if p.GTIN == "" && p.MPN == "" {
+		return "no"
+	}
+	return ""
+}
+
+type merchantRSS struct {
+	XMLName   xml.Name           `xml:"rss"`
+	Version   string             `xml:"version,attr"`
+	Namespace string             `xml:"xmlns:g,attr"`
+	Channel   merchantXMLChannel `xml:"channel"`
+}
+
+type merchantXMLChannel struct {
+	Title       string            `xml:"title"`
+	Link        string            `xml:"link"`
+	Description string            `xml:"description"`
+	Items       []merchantXMLItem `xml:"item"`
+}
+
+type merchantXMLItem struct {
+	ID                   string   `xml:"g:id"`
+	Title                string   `xml:"g:title"`
+	Description          string   `xml:"g:description"`
+	Link                 string   `xml:"g:link"`
+	ImageLink            string   `xml:"g:image_link"`
+	AdditionalImageLinks []string `xml:"g:additional_image_link"`
+	Availability         string   `xml:"g:availability"`
+	Price                string   `xml:"g:price"`
+	Brand                string   `xml:"g:brand"`
+	GTIN                 string   `xml:"g:gtin,omitempty"`
+	MPN                  string   `xml:"g:mpn,omitempty"`
+	Condition            string   `xml:"g:condition,omitempty"`
+	IdentifierExists     string   `xml:"g:identifier_exists,omitempty"`
+}
+
+// MerchantXML writes products as a Google Merchant Center RSS 2.0 feed.
+func MerchantXML(channel MerchantChannel, products []*MerchantProduct) ([]byte, error) {
+	feed := merchantRSS{
+		Version:   "2.0",
+		Namespace: "http://base.google.com/ns/1.0",
+		Channel: merchantXMLChannel{
+			Title:       channel.Title,
+			Link:        channel.Link,
+			Description: channel.Description,
+			Items:       make([]merchantXMLItem, 0, len(products)),
+		},
+	}
+
+	for _, p := range products {
+		feed.Channel.Items = append(feed.Channel.Items, merchantXMLItem{
+			ID:                   p.ID,
+			Title:                p.Title,
+			Description:          p.Description,
+			Link:                 p.Link,
+			ImageLink:            p.ImageLink,
+			AdditionalImageLinks: p.AdditionalImageLinks,
+			Availability:         p.Availability,
+			Price:                p.Price,
+			Brand:                p.Brand,
+			GTIN:                 p.GTIN,
+			MPN:                  p.MPN,
+			Condition:            p.Condition,
+			IdentifierExists:     p.identifierExists(),
+		})
+	}
+
+	b, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// merchantTSVColumns are the attribute names written as the header of a TSV
+// feed.
+var merchantTSVColumns = []string{"id", "title", "description", "link", "image_link", "additional_image_link", "availability", "price", "brand", "gtin", "mpn", "condition", "identifier_exists"}
+
+// MerchantTSV writes products as a tab separated Merchant Center feed with a
+// header row. Tabs and line breaks within values are replaced by spaces and
+// additional image links are separated by commas.
+func MerchantTSV(products []*MerchantProduct) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(strings.Join(merchantTSVColumns, "\t"))
+	buf.WriteString("\n")
+
+	for _, p := range products {
+		fields := []string{p.ID, p.Title, p.Description, p.Link, p.ImageLink, strings.Join(p.AdditionalImageLinks, ","), p.Availability, p.Price, p.Brand, p.GTIN, p.MPN, p.Condition, p.identifierExists()}
+		for i, f := range fields {
+			fields[i] = strings.Join(strings.FieldsFunc(f, func(r rune) bool { return r == '\t' || r == '\n' || r == '\r' }), " ")
+		}
+		buf.WriteString(strings.Join(fields, "\t"))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,127 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"reflect"
+	"testing"
+)
+
+const merchantHTML = `<div itemscope itemtype="http://schema.org/Product">
+  <a itemprop="url" href="/widget"><span itemprop="name">Widget</span></a>
+  <p itemprop="description">A very useful widget.</p>
+  <img itemprop="image" src="/widget.jpg"><img itemprop="image" src="/widget-2.jpg">
+  <meta itemprop="sku" content="W-1">
+  <meta itemprop="gtin13" content="4006381333931">
+  <div itemprop="brand" itemscope itemtype="http://schema.org/Brand"><span itemprop="name">ACME</span></div>
+  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+    <meta itemprop="price" content="15.00"><meta itemprop="priceCurrency" content="USD">
+    <link itemprop="availability" href="http://schema.org/InStock">
+    <link itemprop="itemCondition" href="http://schema.org/NewCondition">
+  </div>
+</div>
+<div itemscope itemtype="http://schema.org/Product">
+  <span itemprop="name">Gadget</span>
+  <meta itemprop="sku" content="G-1">
+</div>`
+
+func TestMerchantProducts(t *testing.T) {
+	products, problems := MerchantProducts(ParseData(merchantHTML, t))
+
+	expected := []*MerchantProduct{{
+		ID:                   "W-1",
+		Title:                "Widget",
+		Description:          "A very useful widget.",
+		Link:                 "http://example.com/widget",
+		ImageLink:            "http://example.com/widget.jpg",
+		AdditionalImageLinks: []string{"http://example.com/widget-2.jpg"},
+		Availability:         "in_stock",
+		Price:                "15.00 USD",
+		Brand:                "ACME",
+		GTIN:                 "4006381333931",
+		Condition:            "new",
+	}}
+	if !reflect.DeepEqual(products, expected) {
+		t.Errorf("Expecting %+v but got %+v", expected[0], products)
+	}
+
+	if len(problems) != 1 {
+		t.Fatalf("Expecting 1 problem but got %d", len(problems))
+	}
+	missing := []string{"description", "link", "image_link", "availability", "price", "brand"}
+	if problems[0].ID != "G-1" || !reflect.DeepEqual(problems[0].Missing, missing) {
+		t.Errorf("Expecting problem for G-1 missing %v but got %+v", missing, problems[0])
+	}
+}
+
+func TestMerchantProductsImageAndNilSet(t *testing.T) {
+	item := NewItem()
+	item.AddType("http://schema.org/Product")
+	item.AddString("image", " ")
+	item.AddString("image", "http://example.com/a.jpg")
+	item.AddString("image", "http://example.com/b.jpg")
+	data := NewMicrodata()
+	data.AddItem(item)
+
+	p := merchantProduct(item)
+	if p.ImageLink != "http://example.com/a.jpg" || !reflect.DeepEqual(p.AdditionalImageLinks, []string{"http://example.com/b.jpg"}) {
+		t.Errorf("Expecting image links from the non-empty images but got %q and %v", p.ImageLink, p.AdditionalImageLinks)
+	}
+
+	_, problems := MerchantProducts(nil, data)
+	if len(problems) != 1 {
+		t.Fatalf("Expecting 1 problem but got %d", len(problems))
+	}
+}
+
+func TestMerchantXML(t *testing.T) {
+	products, _ := MerchantProducts(ParseData(merchantHTML, t))
+
+	b, err := MerchantXML(MerchantChannel{Title: "Shop", Link: "http://example.com/", Description: "Products"}, products)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">
+  <channel>
+    <title>Shop</title>
+    <link>http://example.com/</link>
+    <description>Products</description>
+    <item>
+      <g:id>W-1</g:id>
+      <g:title>Widget</g:title>
+      <g:description>A very useful widget.</g:description>
+      <g:link>http://example.com/widget</g:link>
+      <g:image_link>http://example.com/widget.jpg</g:image_link>
+      <g:additional_image_link>http://example.com/widget-2.jpg</g:additional_image_link>
+      <g:availability>in_stock</g:availability>
+      <g:price>15.00 USD</g:price>
+      <g:brand>ACME</g:brand>
+      <g:gtin>4006381333931</g:gtin>
+      <g:condition>new</g:condition>
+    </item>
+  </channel>
+</rss>`
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
+
+func TestMerchantTSV(t *testing.T) {
+	products := []*MerchantProduct{{ID: "G-1", Title: "Gadget\twith tab", Price: "1.00 EUR", Brand: "ACME"}}
+
+	b, err := MerchantTSV(products)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := "id\ttitle\tdescription\tlink\timage_link\tadditional_image_link\tavailability\tprice\tbrand\tgtin\tmpn\tcondition\tidentifier_exists\n" +
+		"G-1\tGadget with tab\t\t\t\t\t\t1.00 EUR\tACME\t\t\t\tno\n"
+	if string(b) != expected {
+		t.Errorf("Expecting %q but got %q", expected, b)
+	}
+}
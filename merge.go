@@ -0,0 +1,265 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+// ItemByID returns the first item in the set whose ID matches id, searching
+// nested items as well as top-level ones in document order. It returns nil if
+// no item has that ID.
+func (m *Microdata) ItemByID(id string) *Item {
+	if id == "" {
+		return nil
+	}
+	seen := make(map[*Item]bool)
+	for _, item := range m.Items {
+		if found := findItemByID(item, id, seen); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findItemByID(item *Item, id string, seen map[*Item]bool) *Item {
+	if seen[item] {
+		return nil
+	}
+	seen[item] = true
+
+	if item.ID == id {
+		return item
+	}
+	for _, name := range item.PropertyNames() {
+		for _, v := range item.Properties[name] {
+			if child, ok := v.(*Item); ok {
+				if found := findItemByID(child, id, seen); found != nil {
+					return found
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Merge combines other into the item. Types of other that are not already
+// present are appended and property values that are not already present are
+// added. Nested items are treated as the same value when they share a
+// non-empty ID or, lacking IDs, are Equal.
+func (i *Item) Merge(other *Item) {
+	if i.ID == "" {
+		i.ID = other.ID
+	}
+
+	for _, t := range other.Types {
+		if !hasString(i.Types, t) {
+			i.AddType(t)
+		}
+	}
+
+	for _, name := range other.PropertyNames() {
+		for _, v := range other.Properties[name] {
+			if !containsValue(i.Properties[name], v) {
+				i.addValue(name, v)
+			}
+		}
+	}
+}
+
+// Merge returns a new microdata set containing the items of all the given
+// sets. Items that share a non-empty ID, whether they come from the same set
+// or from different ones and whether they are top-level or nested, are
+// unified into a single item combining their types and property values. The
+// unified item takes the position of the first top-level item with that ID
+// and replaces each nested one. The result is built from copies, so the given
+// sets are not changed.
+func Merge(sets ...*Microdata) *Microdata {
+	id := func(item *Item) string { return item.ID }
+	merged, _ := mergeItems(sets, id, id)
+	return merged
+}
+
+// itemMerger unifies the items of several sets that have the same non-empty
+// key, copying them so that the sets it reads are not changed.
+type itemMerger struct {
+	topKey, nestedKey func(*Item) string
+	topLevel          map[*Item]bool
+	groups            map[string][]*Item // items with each key, in the order found
+	unified           map[string]*Item
+	copies            map[*Item]*Item
+}
+
+// mergeItems unifies the items of sets, top-level and nested, that have the
+// same non-empty key. Top-level items are keyed by topKey and nested items by
+// nestedKey. It also returns the item of the result that each top-level item
+// of sets became.
+func mergeItems(sets []*Microdata, topKey, nestedKey func(*Item) string) (*Microdata, map[*Item]*Item) {
+	mg := &itemMerger{
+		topKey:    topKey,
+		nestedKey: nestedKey,
+		topLevel:  make(map[*Item]bool),
+		groups:    make(map[string][]*Item),
+		unified:   make(map[string]*Item),
+		copies:    make(map[*Item]*Item),
+	}
+
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		for _, item := range set.Items {
+			mg.topLevel[item] = true
+		}
+	}
+
+	seen := make(map[*Item]bool)
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		for _, item := range set.Items {
+			mg.group(item, seen)
+		}
+	}
+
+	merged := NewMicrodata()
+	results := make(map[*Item]*Item)
+	added := make(map[*Item]bool)
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		for _, item := range set.Items {
+			result := mg.item(item)
+			results[item] = result
+			if !added[result] {
+				added[result] = true
+				merged.AddItem(result)
+			}
+		}
+	}
+	return merged, results
+}
+
+// key returns the key of an item, which depends on whether it is a top-level
+// item of any of the sets.
+func (mg *itemMerger) key(item *Item) string {
+	if mg.topLevel[item] {
+		return mg.topKey(item)
+	}
+	return mg.nestedKey(item)
+}
+
+// group records every keyed item reachable from item under its key.
+func (mg *itemMerger) group(item *Item, seen map[*Item]bool) {
+	if seen[item] {
+		return
+	}
+	seen[item] = true
+
+	if k := mg.key(item); k != "" {
+		mg.groups[k] = append(mg.groups[k], item)
+	}
+	for _, name := range item.PropertyNames() {
+		for _, v := range item.Properties[name] {
+			if child, ok := v.(*Item); ok {
+				mg.group(child, seen)
+			}
+		}
+	}
+}
+
+// item returns the result item for an item of the input: the unified item
+// for its key, or a copy if it has none.
+func (mg *itemMerger) item(item *Item) *Item {
+	k := mg.key(item)
+	if k == "" {
+		if c, exists := mg.copies[item]; exists {
+			return c
+		}
+		c := NewItem()
+		mg.copies[item] = c
+		c.ID = item.ID
+		c.Types = append(c.Types, item.Types...)
+		for _, name := range item.PropertyNames() {
+			for _, v := range item.Properties[name] {
+				if child, ok := v.(*Item); ok {
+					v = mg.item(child)
+				}
+				c.addValue(name, v)
+			}
+		}
+		return c
+	}
+
+	if target, exists := mg.unified[k]; exists {
+		return target
+	}
+	target := NewItem()
+	mg.unified[k] = target
+	for _, member := range mg.groups[k] {
+		mg.mergeInto(target, member)
+	}
+	return target
+}
+
+// mergeInto merges an item of the input into a unified item as Item.Merge
+// does, replacing nested items with their result items.
+func (mg *itemMerger) mergeInto(target, item *Item) {
+	if target.ID == "" {
+		target.ID = item.ID
+	}
+
+	for _, t := range item.Types {
+		if !hasString(target.Types, t) {
+			target.AddType(t)
+		}
+	}
+
+	for _, name := range item.PropertyNames() {
+		for _, v := range item.Properties[name] {
+			if child, ok := v.(*Item); ok {
+				v = mg.item(child)
+			}
+			if !containsValue(target.Properties[name], v) {
+				target.addValue(name, v)
+			}
+		}
+	}
+}
+
+func containsValue(values valueList, v interface{}) bool {
+	for _, existing := range values {
+		if sameValue(existing, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameValue(a, b interface{}) bool {
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case *Item:
+		bv, ok := b.(*Item)
+		if !ok {
+			return false
+		}
+		if av.ID != "" || bv.ID != "" {
+			return av.ID == bv.ID
+		}
+		return av.Equal(bv)
+	}
+	return false
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
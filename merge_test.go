@@ -0,0 +1,144 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"testing"
+)
+
+func TestItemByID(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Offer">
+	  <div itemprop="itemOffered" itemscope itemtype="http://schema.org/Product" itemid="urn:sku:123">
+	    <span itemprop="name">Widget</span>
+	  </div>
+	</div>`
+
+	data := ParseData(html, t)
+
+	item := data.ItemByID("urn:sku:123")
+	if item == nil {
+		t.Fatalf("Expecting nested item to be found by id")
+	}
+	if item.Properties["name"][0].(string) != "Widget" {
+		t.Errorf("Expecting name 'Widget' but got %v", item.Properties["name"])
+	}
+
+	if data.ItemByID("urn:sku:999") != nil {
+		t.Errorf("Expecting nil for unknown id")
+	}
+}
+
+func TestMergeWithinDocument(t *testing.T) {
+	html := `<body>
+	  <div itemscope itemtype="http://schema.org/Product" itemid="urn:sku:123">
+	    <span itemprop="name">Widget</span>
+	  </div>
+	  <div itemscope itemtype="http://schema.org/Thing">
+	    <span itemprop="name">Unrelated</span>
+	  </div>
+	  <div itemscope itemtype="http://schema.org/Product http://schema.org/IndividualProduct" itemid="urn:sku:123">
+	    <span itemprop="name">Widget</span>
+	    <span itemprop="color">Red</span>
+	  </div>
+	</body>`
+
+	merged := Merge(ParseData(html, t))
+
+	if len(merged.Items) != 2 {
+		t.Fatalf("Expecting 2 items but got %d", len(merged.Items))
+	}
+
+	item := merged.Items[0]
+	if item.ID != "urn:sku:123" {
+		t.Errorf("Expecting merged item first but got id %q", item.ID)
+	}
+	if len(item.Types) != 2 {
+		t.Errorf("Expecting 2 types but got %v", item.Types)
+	}
+	if len(item.Properties["name"]) != 1 {
+		t.Errorf("Expecting duplicate name to be removed but got %v", item.Properties["name"])
+	}
+	if len(item.Properties["color"]) != 1 {
+		t.Errorf("Expecting color property but got %v", item.Properties["color"])
+	}
+}
+
+func TestMergeAcrossDocuments(t *testing.T) {
+	a := NewItem()
+	a.ID = "http://example.com/p/1"
+	a.AddString("name", "Widget")
+
+	b := NewItem()
+	b.ID = "http://example.com/p/1"
+	b.AddString("name", "Widget deluxe")
+
+	setA := NewMicrodata()
+	setA.AddItem(a)
+	setB := NewMicrodata()
+	setB.AddItem(b)
+
+	merged := Merge(setA, setB)
+
+	if len(merged.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(merged.Items))
+	}
+	if len(merged.Items[0].Properties["name"]) != 2 {
+		t.Errorf("Expecting 2 names but got %v", merged.Items[0].Properties["name"])
+	}
+	if len(a.Properties["name"]) != 1 {
+		t.Errorf("Expecting input item to be unchanged but got %v", a.Properties["name"])
+	}
+}
+
+func TestMergeNestedItems(t *testing.T) {
+	html := `<body>
+	  <div itemscope itemtype="http://schema.org/Product" itemid="urn:x">
+	    <span itemprop="name">Widget</span>
+	  </div>
+	  <div itemscope itemtype="http://schema.org/Offer">
+	    <div itemprop="itemOffered" itemscope itemtype="http://schema.org/Product" itemid="urn:x">
+	      <span itemprop="color">Red</span>
+	    </div>
+	  </div>
+	</body>`
+
+	data := ParseData(html, t)
+	merged := Merge(data)
+
+	if len(merged.Items) != 2 {
+		t.Fatalf("Expecting 2 items but got %d", len(merged.Items))
+	}
+	product := merged.Items[0]
+	if len(product.Properties["name"]) != 1 || len(product.Properties["color"]) != 1 {
+		t.Errorf("Expecting top-level product to have name and color but got %v", product.Properties)
+	}
+	offered, ok := merged.Items[1].Properties["itemOffered"][0].(*Item)
+	if !ok || offered != product {
+		t.Errorf("Expecting nested product to be the unified item but got %v", merged.Items[1].Properties["itemOffered"])
+	}
+
+	if merged.Items[1] == data.Items[1] {
+		t.Errorf("Expecting items without an id to be copied")
+	}
+	if _, exists := data.Items[0].Properties["color"]; exists {
+		t.Errorf("Expecting input item to be unchanged but got %v", data.Items[0].Properties)
+	}
+}
+
+func TestItemByIDDocumentOrder(t *testing.T) {
+	html := `<div itemscope>
+	  <div itemprop="a" itemscope itemtype="http://schema.org/Thing" itemid="urn:x"><span itemprop="name">First</span></div>
+	  <div itemprop="b" itemscope itemtype="http://schema.org/Thing" itemid="urn:x"><span itemprop="name">Second</span></div>
+	  <div itemprop="c" itemscope itemtype="http://schema.org/Thing" itemid="urn:x"><span itemprop="name">Third</span></div>
+	</div>`
+
+	data := ParseData(html, t)
+	for i := 0; i < 20; i++ {
+		if name := data.ItemByID("urn:x").Properties["name"][0]; name != "First" {
+			t.Fatalf("Expecting the first item in document order but got %v", name)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Namespaces of the items produced from meta tags.
+const (
+	// OpenGraphNS is the Open Graph namespace. The Open Graph item is typed
+	// with this namespace followed by its og:type, such as
+	// http://ogp.me/ns#article.
+	OpenGraphNS = "http://ogp.me/ns#"
+
+	// TwitterCardNS is the namespace of Twitter Card items, which are typed
+	// with this namespace followed by the card type, such as
+	// https://dev.twitter.com/cards#summary.
+	TwitterCardNS = "https://dev.twitter.com/cards#"
+
+	// DCElementsNS and DCTermsNS are the Dublin Core namespaces used for the
+	// properties of the Dublin Core item.
+	DCElementsNS = "http://purl.org/dc/elements/1.1/"
+	DCTermsNS    = "http://purl.org/dc/terms/"
+)
+
+// openGraphStructured lists the Open Graph properties whose values are
+// structured: og:image:width, for example, describes the preceding og:image.
+var openGraphStructured = map[string]bool{
+	"image": true,
+	"video": true,
+	"audio": true,
+}
+
+// openGraphURLs lists the Open Graph properties whose values are URLs.
+var openGraphURLs = map[string]bool{
+	"url":        true,
+	"image":      true,
+	"video":      true,
+	"audio":      true,
+	"secure_url": true,
+}
+
+// openGraphVerticals are the prefixes of the type specific Open Graph
+// properties, such as article:published_time.
+var openGraphVerticals = map[string]bool{
+	"article": true,
+	"book":    true,
+	"profile": true,
+	"music":   true,
+	"video":   true,
+}
+
+// MetaParser is an HTML parser that extracts Open Graph, Twitter Card and
+// Dublin Core meta tags as microdata items
+type MetaParser struct {
+	r    io.Reader
+	data *Microdata
+	base *url.URL
+}
+
+// NewMetaParser creates a new parser for extracting meta tags
+// r is a reader over an HTML document
+// base is the base URL for resolving relative URLs
+func NewMetaParser(r io.Reader, base *url.URL) *MetaParser {
+	return &MetaParser{
+		r:    r,
+		data: NewMicrodata(),
+		base: base,
+	}
+}
+
+// Parse the document and return a Microdata set holding up to three items, in
+// this order:
+//
+// An Open Graph item typed with OpenGraphNS and the og:type, defaulting to
+// website, and identified by og:url. og:title becomes the title property and
+// so on. og:image, og:video and og:audio values are items with a url property
+// and the properties given by the tags that follow, such as og:image:width.
+// Type specific properties such as article:published_time keep their prefix.
+//
+// A Twitter Card item typed with TwitterCardNS and the twitter:card value.
+// twitter:title becomes the title property and so on.
+//
+// A Dublin Core item identified by the base URL whose properties are the
+// DCElementsNS or DCTermsNS URLs of DC.* and DCTERMS.* tags. Qualified names
+// such as DC.date.issued use the Dublin Core term for the refinement.
+func (p *MetaParser) Parse() (*Microdata, error) {
+	tree, err := html.Parse(p.r)
+	if err != nil {
+		return nil, err
+	}
+	p.parseTree(tree)
+	return p.data, nil
+}
+
+func (p *MetaParser) parseTree(tree *html.Node) {
+	og, twitter, dc := NewItem(), NewItem(), NewItem()
+	var ogType, card string
+	var media *Item // the og:image, og:video or og:audio item being described
+	var mediaName string
+
+	walk(tree, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Meta {
+			return
+		}
+		content, exists := getAttr("content", n)
+		content = strings.TrimSpace(content)
+		if !exists || content == "" {
+			return
+		}
+		key, exists := getAttr("property", n)
+		if !exists {
+			key, _ = getAttr("name", n)
+		}
+		key = strings.TrimSpace(key)
+		lower := strings.ToLower(key)
+
+		switch {
+		case strings.HasPrefix(lower, "og:"):
+			parts := strings.Split(lower[3:], ":")
+			name := parts[0]
+			switch {
+			case name == "type" && len(parts) == 1:
+				ogType = content
+			case openGraphStructured[name] && (len(parts) == 1 || parts[1] == "url"):
+				// og:image:url is the same as og:image, so may repeat it
+				if len(parts) > 1 && media != nil && mediaName == name {
+					urls := media.Properties["url"]
+					if len(urls) == 0 {
+						media.AddString("url", p.resolve(content))
+						return
+					}
+					if urls[0] == p.resolve(content) {
+						return
+					}
+				}
+				media, mediaName = NewItem(), name
+				media.AddString("url", p.resolve(content))
+				og.AddItem(name, media)
+			case openGraphStructured[name]:
+				if media == nil || mediaName != name {
+					media, mediaName = NewItem(), name
+					og.AddItem(name, media)
+				}
+				value := content
+				if openGraphURLs[parts[1]] {
+					value = p.resolve(content)
+				}
+				media.AddString(strings.Join(parts[1:], ":"), value)
+			default:
+				if name == "url" && len(parts) == 1 {
+					content = p.resolve(content)
+					if og.ID == "" {
+						og.ID = content
+					}
+				}
+				og.AddString(strings.Join(parts, ":"), content)
+			}
+		case strings.Contains(lower, ":") && openGraphVerticals[lower[:strings.Index(lower, ":")]]:
+			og.AddString(lower, content)
+		case strings.HasPrefix(lower, "twitter:"):
+			name := lower[len("twitter:"):]
+			if name == "card" {
+				card = content
+				return
+			}
+			if name == "image" || name == "image:src" || name == "player" {
+				content = p.resolve(content)
+			}
+			twitter.AddString(name, content)
+		case strings.HasPrefix(lower, "dcterms."):
+			dc.AddString(DCTermsNS+dcTerm(key[len("dcterms."):]), content)
+		case strings.HasPrefix(lower, "dc."):
+			term := key[len("dc."):]
+			if i := strings.LastIndex(term, "."); i >= 0 {
+				dc.AddString(DCTermsNS+dcTerm(term[i+1:]), content)
+			} else {
+				dc.AddString(DCElementsNS+dcTerm(term), content)
+			}
+		}
+	})
+
+	if len(og.Properties) > 0 || ogType != "" {
+		if ogType == "" {
+			ogType = "website"
+		}
+		og.AddType(OpenGraphNS + ogType)
+		p.data.AddItem(og)
+	}
+	if len(twitter.Properties) > 0 || card != "" {
+		if card != "" {
+			twitter.AddType(TwitterCardNS + card)
+		}
+		p.data.AddItem(twitter)
+	}
+	if len(dc.Properties) > 0 {
+		if p.base != nil {
+			dc.ID = p.base.String()
+		}
+		p.data.AddItem(dc)
+	}
+}
+
+// resolve resolves a URL found in a meta tag, leaving it unchanged if it cannot
+// be parsed.
+func (p *MetaParser) resolve(ref string) string {
+	if resolved, ok := resolveURL(p.base, ref); ok {
+		return resolved
+	}
+	return ref
+}
+
+// dcTerm returns the Dublin Core term for a meta tag name, which is often
+// capitalised, as in DC.Title.
+func dcTerm(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,132 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func ParseMetaData(html string, t *testing.T) *Microdata {
+	u, _ := url.Parse("http://example.com/post")
+	data, err := NewMetaParser(strings.NewReader(html), u).Parse()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return data
+}
+
+func TestMetaOpenGraph(t *testing.T) {
+	html := `<html><head>
+	<meta property="og:type" content="article">
+	<meta property="og:title" content="Hello">
+	<meta property="og:url" content="/post">
+	<meta property="og:image" content="/a.png">
+	<meta property="og:image:width" content="400">
+	<meta property="og:image:url" content="/a.png">
+	<meta property="og:image" content="/b.png">
+	<meta property="og:image:secure_url" content="https://example.com/b.png">
+	<meta property="og:image:alt" content="B">
+	<meta property="article:published_time" content="2020-01-02">
+	<meta property="og:description" content="">
+	</head></html>`
+
+	data := ParseMetaData(html, t)
+	if len(data.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(data.Items))
+	}
+
+	og := data.Items[0]
+	if og.Types[0] != "http://ogp.me/ns#article" {
+		t.Errorf("Expecting type http://ogp.me/ns#article but got %s", og.Types[0])
+	}
+	if og.ID != "http://example.com/post" {
+		t.Errorf("Expecting id http://example.com/post but got %s", og.ID)
+	}
+	if og.Properties["title"][0].(string) != "Hello" {
+		t.Errorf("Expecting title Hello but got %v", og.Properties["title"][0])
+	}
+	if og.Properties["article:published_time"][0].(string) != "2020-01-02" {
+		t.Errorf("Expecting article:published_time 2020-01-02 but got %v", og.Properties["article:published_time"])
+	}
+	if _, exists := og.Properties["description"]; exists {
+		t.Errorf("Expecting empty description to be skipped")
+	}
+	if names := strings.Join(og.PropertyNames(), ","); names != "title,url,image,article:published_time" {
+		t.Errorf("Expecting properties title,url,image,article:published_time but got %s", names)
+	}
+
+	images := og.Properties["image"]
+	if len(images) != 2 {
+		t.Fatalf("Expecting 2 images but got %d", len(images))
+	}
+	first, second := images[0].(*Item), images[1].(*Item)
+	if first.Properties["url"][0].(string) != "http://example.com/a.png" || len(first.Properties["url"]) != 1 {
+		t.Errorf("Expecting image url http://example.com/a.png but got %v", first.Properties["url"])
+	}
+	if first.Properties["width"][0].(string) != "400" {
+		t.Errorf("Expecting image width 400 but got %v", first.Properties["width"])
+	}
+	if second.Properties["secure_url"][0].(string) != "https://example.com/b.png" {
+		t.Errorf("Expecting image secure_url https://example.com/b.png but got %v", second.Properties["secure_url"])
+	}
+	if second.Properties["alt"][0].(string) != "B" {
+		t.Errorf("Expecting image alt B but got %v", second.Properties["alt"])
+	}
+}
+
+func TestMetaTwitterAndDublinCore(t *testing.T) {
+	html := `<html><head>
+	<meta name="twitter:card" content="summary_large_image">
+	<meta name="twitter:site" content="@example">
+	<meta name="twitter:image" content="/card.png">
+	<meta name="DC.Title" content="A Report">
+	<meta name="DC.creator" content="Jane Doe">
+	<meta name="DC.date.issued" content="2019-05-01">
+	<meta name="DCTERMS.modified" content="2019-06-01">
+	<meta name="description" content="Not structured">
+	</head></html>`
+
+	data := ParseMetaData(html, t)
+	if len(data.Items) != 2 {
+		t.Fatalf("Expecting 2 items but got %d", len(data.Items))
+	}
+
+	twitter := data.Items[0]
+	if twitter.Types[0] != "https://dev.twitter.com/cards#summary_large_image" {
+		t.Errorf("Expecting type https://dev.twitter.com/cards#summary_large_image but got %s", twitter.Types[0])
+	}
+	if twitter.Properties["site"][0].(string) != "@example" {
+		t.Errorf("Expecting site @example but got %v", twitter.Properties["site"])
+	}
+	if twitter.Properties["image"][0].(string) != "http://example.com/card.png" {
+		t.Errorf("Expecting image http://example.com/card.png but got %v", twitter.Properties["image"])
+	}
+
+	dc := data.Items[1]
+	if dc.ID != "http://example.com/post" {
+		t.Errorf("Expecting id http://example.com/post but got %s", dc.ID)
+	}
+	expected := map[string]string{
+		"http://purl.org/dc/elements/1.1/title":   "A Report",
+		"http://purl.org/dc/elements/1.1/creator": "Jane Doe",
+		"http://purl.org/dc/terms/issued":         "2019-05-01",
+		"http://purl.org/dc/terms/modified":       "2019-06-01",
+	}
+	for name, value := range expected {
+		if values := dc.Properties[name]; len(values) != 1 || values[0].(string) != value {
+			t.Errorf("Expecting %s to be %s but got %v", name, value, values)
+		}
+	}
+}
+
+func TestMetaNone(t *testing.T) {
+	data := ParseMetaData(`<html><head><meta name="description" content="Plain"></head></html>`, t)
+	if len(data.Items) != 0 {
+		t.Errorf("Expecting no items but got %d", len(data.Items))
+	}
+}
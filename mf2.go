@@ -0,0 +1,403 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MicroformatsNS is the namespace of microformats2 items, which are typed with
+// this namespace followed by the root class name, such as
+// http://microformats.org/profile/h-card.
+const MicroformatsNS = "http://microformats.org/profile/"
+
+// MF2Parser is an HTML parser that extracts microformats2 as microdata items
+type MF2Parser struct {
+	r    io.Reader
+	data *Microdata
+	base *url.URL
+	rels map[string][]string
+}
+
+// NewMF2Parser creates a new parser for extracting microformats2
+// r is a reader over an HTML document
+// base is the base URL for resolving relative URLs
+func NewMF2Parser(r io.Reader, base *url.URL) *MF2Parser {
+	return &MF2Parser{
+		r:    r,
+		data: NewMicrodata(),
+		base: base,
+		rels: make(map[string][]string),
+	}
+}
+
+// Parse the document and return a Microdata set. Each element with an h-*
+// class is an item typed with MicroformatsNS followed by the class name.
+// Elements with p-*, u-*, dt-* and e-* classes add properties, named without
+// their prefix, to the nearest enclosing item: p-* values are plain text,
+// u-* values are resolved URLs, dt-* values are dates and e-* values are the
+// element's inner HTML. An item on an element that is also a property is the
+// value of that property; other nested items are values of the children
+// property. Items without explicit name, photo or url properties are given
+// values implied by their markup as described by the microformats2 parsing
+// specification.
+func (p *MF2Parser) Parse() (*Microdata, error) {
+	tree, err := html.Parse(p.r)
+	if err != nil {
+		return nil, err
+	}
+	p.parseTree(tree)
+	return p.data, nil
+}
+
+// Rels returns the URLs of the links in the document by rel value, such as
+// "me" or "webmention", as found by the last call to Parse.
+func (p *MF2Parser) Rels() map[string][]string {
+	return p.rels
+}
+
+// mf2Item is an item being parsed with the kinds of properties found so far,
+// which decide the properties that may be implied.
+type mf2Item struct {
+	item             *Item
+	hasText, hasURLs bool // found p-* or e-*, and u-* properties
+	hasNested        bool // found nested items
+}
+
+func (p *MF2Parser) parseTree(tree *html.Node) {
+	walk(tree, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.A && n.DataAtom != atom.Link && n.DataAtom != atom.Area) {
+			return
+		}
+		rel, hasRel := getAttr("rel", n)
+		href, hasHref := getAttr("href", n)
+		if !hasRel || !hasHref {
+			return
+		}
+		link, ok := resolveURL(p.base, href)
+		if !ok {
+			return
+		}
+		for _, r := range strings.Fields(strings.ToLower(rel)) {
+			if !hasString(p.rels[r], link) {
+				p.rels[r] = append(p.rels[r], link)
+			}
+		}
+	})
+
+	p.readNode(nil, tree)
+}
+
+func (p *MF2Parser) readNode(parent *mf2Item, node *html.Node) {
+	if node.Type != html.ElementNode {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			p.readNode(parent, child)
+		}
+		return
+	}
+
+	roots, props := mf2Classes(node)
+
+	if len(roots) > 0 {
+		current := p.readItem(node, roots)
+		switch {
+		case parent == nil:
+			p.data.AddItem(current)
+		case len(props) > 0:
+			for _, prop := range props {
+				parent.item.AddItem(prop[strings.Index(prop, "-")+1:], current)
+			}
+			parent.hasNested = true
+		default:
+			parent.item.AddItem("children", current)
+			parent.hasNested = true
+		}
+		return
+	}
+
+	if parent != nil {
+		for _, prop := range props {
+			prefix, name := prop[:strings.Index(prop, "-")], prop[strings.Index(prop, "-")+1:]
+			switch prefix {
+			case "p":
+				parent.item.AddString(name, mf2Text(node))
+				parent.hasText = true
+			case "u":
+				parent.item.AddString(name, p.urlProperty(node))
+				parent.hasURLs = true
+			case "dt":
+				parent.item.AddString(name, mf2Date(node))
+			case "e":
+				parent.item.AddString(name, innerHTML(node))
+				parent.hasText = true
+			}
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		p.readNode(parent, child)
+	}
+}
+
+// readItem reads the item rooted at node and adds its implied properties.
+func (p *MF2Parser) readItem(node *html.Node, roots []string) *Item {
+	current := &mf2Item{item: NewItem()}
+	for _, root := range roots {
+		current.item.AddType(MicroformatsNS + root)
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		p.readNode(current, child)
+	}
+
+	item := current.item
+	if _, exists := item.Properties["name"]; !exists && !current.hasText && !current.hasNested {
+		if name := mf2ImpliedName(node); name != "" {
+			item.AddString("name", name)
+		}
+	}
+	if _, exists := item.Properties["photo"]; !exists && !current.hasURLs && !current.hasNested {
+		if photo := p.impliedURL(node, mf2Source{atom.Img, "src"}, mf2Source{atom.Object, "data"}); photo != "" {
+			item.AddString("photo", photo)
+		}
+	}
+	if _, exists := item.Properties["url"]; !exists && !current.hasURLs && !current.hasNested {
+		if link := p.impliedURL(node, mf2Source{atom.A, "href"}, mf2Source{atom.Area, "href"}); link != "" {
+			item.AddString("url", link)
+		}
+	}
+	return item
+}
+
+// mf2Classes returns the root and property class names of a node.
+func mf2Classes(node *html.Node) (roots, props []string) {
+	class, _ := getAttr("class", node)
+	for _, c := range strings.Fields(class) {
+		switch {
+		case strings.HasPrefix(c, "h-") && len(c) > 2:
+			if !hasString(roots, c) {
+				roots = append(roots, c)
+			}
+		case strings.HasPrefix(c, "p-") && len(c) > 2,
+			strings.HasPrefix(c, "u-") && len(c) > 2,
+			strings.HasPrefix(c, "dt-") && len(c) > 3,
+			strings.HasPrefix(c, "e-") && len(c) > 2:
+			if !hasString(props, c) {
+				props = append(props, c)
+			}
+		}
+	}
+	return roots, props
+}
+
+// mf2Text returns the value of a p-* property.
+func mf2Text(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.Abbr, atom.Link:
+		if title, exists := getAttr("title", node); exists {
+			return title
+		}
+	case atom.Data, atom.Input:
+		if value, exists := getAttr("value", node); exists {
+			return value
+		}
+	case atom.Img, atom.Area:
+		if alt, exists := getAttr("alt", node); exists {
+			return alt
+		}
+	}
+	return strings.TrimSpace(mf2TextContent(node))
+}
+
+// mf2TextContent returns the text of a node as the microformats2 parsing
+// specification defines it: script and style elements are left out and img
+// elements are replaced by their alt text.
+func mf2TextContent(node *html.Node) string {
+	var text bytes.Buffer
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			text.WriteString(n.Data)
+			return
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style):
+			return
+		case n.Type == html.ElementNode && n.DataAtom == atom.Img:
+			alt, _ := getAttr("alt", n)
+			text.WriteString(alt)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(node)
+	return text.String()
+}
+
+// urlProperty returns the value of a u-* property.
+func (p *MF2Parser) urlProperty(node *html.Node) string {
+	var attr string
+	switch node.DataAtom {
+	case atom.A, atom.Area, atom.Link:
+		attr = "href"
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Iframe:
+		attr = "src"
+	case atom.Object:
+		attr = "data"
+	}
+	if ref, exists := getAttr(attr, node); attr != "" && exists {
+		if resolved, ok := resolveURL(p.base, ref); ok {
+			return resolved
+		}
+	}
+	if node.DataAtom == atom.Video {
+		if poster, exists := getAttr("poster", node); exists {
+			if resolved, ok := resolveURL(p.base, poster); ok {
+				return resolved
+			}
+		}
+	}
+	return mf2Text(node)
+}
+
+// mf2Date returns the value of a dt-* property.
+func mf2Date(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.Time, atom.Ins, atom.Del:
+		if datetime, exists := getAttr("datetime", node); exists {
+			return datetime
+		}
+	}
+	return mf2Text(node)
+}
+
+// mf2ImpliedName returns the name implied by an item's root element: the alt
+// or title of the root itself, of its only child element, or of that
+// element's only child, and failing those the root's text.
+func mf2ImpliedName(node *html.Node) string {
+	if name, exists := mf2NameAttr(node); exists {
+		return name
+	}
+	child := onlyChildElement(node)
+	for depth := 0; depth < 2 && child != nil && !isMF2Root(child); depth++ {
+		if name, _ := mf2NameAttr(child); name != "" {
+			return name
+		}
+		child = onlyChildElement(child)
+	}
+	return cleanText(mf2TextContent(node))
+}
+
+// mf2NameAttr returns the alt of an img or area, or the title of an abbr.
+func mf2NameAttr(node *html.Node) (string, bool) {
+	switch node.DataAtom {
+	case atom.Img, atom.Area:
+		return getAttr("alt", node)
+	case atom.Abbr:
+		return getAttr("title", node)
+	}
+	return "", false
+}
+
+// mf2Source is a kind of element holding an implied URL and the attribute
+// holding it.
+type mf2Source struct {
+	atom atom.Atom
+	attr string
+}
+
+// impliedURL returns the resolved URL of the first of sources found on an
+// item's root element or, failing that, on the only element of its kind that
+// is a child of the root or of the root's only child element. Elements that
+// are themselves items are ignored.
+func (p *MF2Parser) impliedURL(node *html.Node, sources ...mf2Source) string {
+	var ref string
+	var found bool
+	for _, src := range sources {
+		if node.DataAtom == src.atom {
+			ref, found = getAttr(src.attr, node)
+		}
+	}
+
+	parents := []*html.Node{node}
+	if child := onlyChildElement(node); child != nil && !isMF2Root(child) {
+		parents = append(parents, child)
+	}
+	for _, parent := range parents {
+		for _, src := range sources {
+			if found {
+				break
+			}
+			if n := onlyChildOfType(parent, src.atom); n != nil && !isMF2Root(n) {
+				ref, found = getAttr(src.attr, n)
+			}
+		}
+	}
+
+	if !found {
+		return ""
+	}
+	resolved, ok := resolveURL(p.base, ref)
+	if !ok {
+		return ""
+	}
+	return resolved
+}
+
+// onlyChildElement returns the only child element of a node, or nil if it has
+// none or several.
+func onlyChildElement(node *html.Node) *html.Node {
+	var only *html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if only != nil {
+			return nil
+		}
+		only = child
+	}
+	return only
+}
+
+// onlyChildOfType returns the only child element of a node of the given kind,
+// or nil if it has none or several.
+func onlyChildOfType(node *html.Node, a atom.Atom) *html.Node {
+	var only *html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != a {
+			continue
+		}
+		if only != nil {
+			return nil
+		}
+		only = child
+	}
+	return only
+}
+
+// isMF2Root reports whether a node has an h-* class.
+func isMF2Root(node *html.Node) bool {
+	roots, _ := mf2Classes(node)
+	return len(roots) > 0
+}
+
+// innerHTML returns the HTML of a node's children.
+func innerHTML(node *html.Node) string {
+	var buf bytes.Buffer
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&buf, child); err != nil {
+			return textContent(node)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
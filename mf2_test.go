@@ -0,0 +1,150 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ParseMF2Data(html string, t *testing.T) (*Microdata, *MF2Parser) {
+	u, _ := url.Parse("http://example.com/")
+	p := NewMF2Parser(strings.NewReader(html), u)
+	data, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return data, p
+}
+
+func TestMF2Entry(t *testing.T) {
+	html := `<article class="h-entry">
+	  <h1 class="p-name">Hello world</h1>
+	  <a class="u-url" href="/2020/hello">permalink</a>
+	  <time class="dt-published" datetime="2020-01-02T10:00:00Z">2 Jan</time>
+	  <div class="p-author h-card"><a class="p-name u-url" href="/">Jane</a></div>
+	  <div class="e-content"><p>First <b>post</b></p></div>
+	  <span class="p-category">go</span> <span class="p-category">web</span>
+	</article>`
+
+	data, _ := ParseMF2Data(html, t)
+	if len(data.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(data.Items))
+	}
+
+	entry := data.Items[0]
+	if entry.Types[0] != "http://microformats.org/profile/h-entry" {
+		t.Errorf("Expecting type http://microformats.org/profile/h-entry but got %s", entry.Types[0])
+	}
+	if entry.Properties["name"][0].(string) != "Hello world" {
+		t.Errorf("Expecting name Hello world but got %v", entry.Properties["name"])
+	}
+	if entry.Properties["url"][0].(string) != "http://example.com/2020/hello" {
+		t.Errorf("Expecting url http://example.com/2020/hello but got %v", entry.Properties["url"])
+	}
+	if entry.Properties["published"][0].(string) != "2020-01-02T10:00:00Z" {
+		t.Errorf("Expecting published 2020-01-02T10:00:00Z but got %v", entry.Properties["published"])
+	}
+	if entry.Properties["content"][0].(string) != "<p>First <b>post</b></p>" {
+		t.Errorf("Expecting content HTML but got %v", entry.Properties["content"])
+	}
+	if len(entry.Properties["category"]) != 2 {
+		t.Errorf("Expecting 2 categories but got %v", entry.Properties["category"])
+	}
+
+	author := entry.Properties["author"][0].(*Item)
+	if author.Types[0] != "http://microformats.org/profile/h-card" {
+		t.Errorf("Expecting author type http://microformats.org/profile/h-card but got %s", author.Types[0])
+	}
+	if author.Properties["name"][0].(string) != "Jane" || author.Properties["url"][0].(string) != "http://example.com/" {
+		t.Errorf("Expecting author Jane at http://example.com/ but got %v", author.Properties)
+	}
+}
+
+func TestMF2ImpliedProperties(t *testing.T) {
+	html := `<a class="h-card" href="/jane"><img src="/jane.jpg" alt="">Jane Doe</a>
+	<div class="h-event"><span class="p-name">Meetup</span><div class="h-card">Bob</div></div>`
+
+	data, _ := ParseMF2Data(html, t)
+	if len(data.Items) != 2 {
+		t.Fatalf("Expecting 2 items but got %d", len(data.Items))
+	}
+
+	card := data.Items[0]
+	if card.Properties["name"][0].(string) != "Jane Doe" {
+		t.Errorf("Expecting implied name Jane Doe but got %v", card.Properties["name"])
+	}
+	if card.Properties["url"][0].(string) != "http://example.com/jane" {
+		t.Errorf("Expecting implied url http://example.com/jane but got %v", card.Properties["url"])
+	}
+	if card.Properties["photo"][0].(string) != "http://example.com/jane.jpg" {
+		t.Errorf("Expecting implied photo http://example.com/jane.jpg but got %v", card.Properties["photo"])
+	}
+
+	event := data.Items[1]
+	child, ok := event.Properties["children"][0].(*Item)
+	if !ok || child.Properties["name"][0].(string) != "Bob" {
+		t.Errorf("Expecting nested h-card named Bob as a child but got %v", event.Properties["children"])
+	}
+}
+
+func TestMF2ImpliedNestedProperties(t *testing.T) {
+	html := `<a class="h-card" href="/jane"><img alt="Jane Doe" src="/jane.jpg"></a>
+	<div class="h-card"><span><abbr title="Bob Smith">Bob</abbr></span></div>
+	<div class="h-card"><p><span>Ann</span><script>var x = 1;</script><style>p {}</style> <img alt="Lee"></p></div>
+	<div class="h-entry"><a href="/post"><img src="/post.jpg"></a><div class="h-card">Sam</div></div>`
+
+	data, _ := ParseMF2Data(html, t)
+	if len(data.Items) != 4 {
+		t.Fatalf("Expecting 4 items but got %d", len(data.Items))
+	}
+
+	jane := data.Items[0]
+	if !reflect.DeepEqual(jane.Properties["name"], valueList{"Jane Doe"}) {
+		t.Errorf("Expecting implied name Jane Doe but got %v", jane.Properties["name"])
+	}
+	if !reflect.DeepEqual(jane.Properties["photo"], valueList{"http://example.com/jane.jpg"}) {
+		t.Errorf("Expecting implied photo http://example.com/jane.jpg but got %v", jane.Properties["photo"])
+	}
+
+	bob := data.Items[1]
+	if !reflect.DeepEqual(bob.Properties["name"], valueList{"Bob Smith"}) {
+		t.Errorf("Expecting implied name Bob Smith but got %v", bob.Properties["name"])
+	}
+
+	ann := data.Items[2]
+	if !reflect.DeepEqual(ann.Properties["name"], valueList{"Ann Lee"}) {
+		t.Errorf("Expecting implied name Ann Lee but got %v", ann.Properties["name"])
+	}
+
+	entry := data.Items[3]
+	if _, exists := entry.Properties["url"]; exists {
+		t.Errorf("Expecting no implied url for an item with nested items but got %v", entry.Properties["url"])
+	}
+	if _, exists := entry.Properties["photo"]; exists {
+		t.Errorf("Expecting no implied photo for an item with nested items but got %v", entry.Properties["photo"])
+	}
+}
+
+func TestMF2Rels(t *testing.T) {
+	html := `<html><head>
+	<link rel="webmention" href="/webmention">
+	<link rel="me authn" href="https://github.com/jane">
+	</head><body><a rel="me" href="https://github.com/jane">GitHub</a><a rel="Tag" href="/tags/go">go</a></body></html>`
+
+	_, p := ParseMF2Data(html, t)
+	expected := map[string][]string{
+		"webmention": {"http://example.com/webmention"},
+		"me":         {"https://github.com/jane"},
+		"authn":      {"https://github.com/jane"},
+		"tag":        {"http://example.com/tags/go"},
+	}
+	if !reflect.DeepEqual(p.Rels(), expected) {
+		t.Errorf("Expecting %v but got %v", expected, p.Rels())
+	}
+}
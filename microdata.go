@@ -10,8 +10,10 @@ package microdata
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/url"
+	"sort"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -26,6 +28,8 @@ type Item struct {
 	Properties propertyMap `json:"properties"`
 	Types      []string    `json:"type,omitempty"`
 	ID         string      `json:"id,omitempty"`
+
+	names []string // property names in the order they were first added
 }
 
 // NewItem creates a new microdata item
@@ -38,19 +42,133 @@ func NewItem() *Item {
 
 // AddString adds a string type item property value
 func (i *Item) AddString(property string, value string) {
-	i.Properties[property] = append(i.Properties[property], value)
+	i.addValue(property, value)
 }
 
 // AddItem adds an Item type item property value
 func (i *Item) AddItem(property string, value *Item) {
+	i.addValue(property, value)
+}
+
+func (i *Item) addValue(property string, value interface{}) {
+	if _, exists := i.Properties[property]; !exists {
+		i.names = append(i.names, property)
+	}
 	i.Properties[property] = append(i.Properties[property], value)
 }
 
+// PropertyNames returns the names of the item's properties in the order they
+// were first added, which for parsed items is the order in which they appear
+// in the document. Names of properties added directly to the Properties map
+// follow in sorted order.
+func (i *Item) PropertyNames() []string {
+	names := make([]string, 0, len(i.Properties))
+	listed := make(map[string]bool, len(i.names))
+	for _, name := range i.names {
+		if _, exists := i.Properties[name]; exists && !listed[name] {
+			names = append(names, name)
+			listed[name] = true
+		}
+	}
+
+	unlisted := make([]string, 0)
+	for name := range i.Properties {
+		if !listed[name] {
+			unlisted = append(unlisted, name)
+		}
+	}
+	sort.Strings(unlisted)
+
+	return append(names, unlisted...)
+}
+
 // AddType adds a type to the item
 func (i *Item) AddType(value string) {
 	i.Types = append(i.Types, value)
 }
 
+// UnmarshalJSON decodes an item from the JSON form written by Microdata.JSON.
+// Property values that are objects are decoded as nested items and strings are
+// kept as strings. Numbers and booleans, which may appear in JSON written by
+// other tools, are decoded as their literal text.
+func (i *Item) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Properties json.RawMessage `json:"properties"`
+		Types      json.RawMessage `json:"type"`
+		ID         string          `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*i = *NewItem()
+	i.ID = raw.ID
+
+	if len(raw.Types) > 0 && !bytes.Equal(raw.Types, []byte("null")) {
+		// A single type may be written as a string rather than a list
+		var itemtype string
+		if err := json.Unmarshal(raw.Types, &itemtype); err == nil {
+			i.AddType(itemtype)
+		} else if err := json.Unmarshal(raw.Types, &i.Types); err != nil {
+			return err
+		}
+	}
+
+	if len(raw.Properties) == 0 || bytes.Equal(raw.Properties, []byte("null")) {
+		return nil
+	}
+
+	// Properties are decoded token by token to keep the order of their names
+	dec := json.NewDecoder(bytes.NewReader(raw.Properties))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("microdata: expected object for properties but got %v", tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+
+		var values []json.RawMessage
+		if err := dec.Decode(&values); err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			value = bytes.TrimSpace(value)
+			if len(value) == 0 {
+				continue
+			}
+			switch value[0] {
+			case '{':
+				child := NewItem()
+				if err := json.Unmarshal(value, child); err != nil {
+					return err
+				}
+				i.AddItem(name, child)
+			case '"':
+				var s string
+				if err := json.Unmarshal(value, &s); err != nil {
+					return err
+				}
+				i.AddString(name, s)
+			case 'n':
+				// null values are ignored
+			case '[':
+				return fmt.Errorf("microdata: unexpected array value for property %q", name)
+			default:
+				i.AddString(name, string(value))
+			}
+		}
+	}
+
+	return nil
+}
+
 // Microdata represents a set of microdata items
 type Microdata struct {
 	Items []*Item `json:"items"`
@@ -77,17 +195,99 @@ func (m *Microdata) JSON() ([]byte, error) {
 	return b, nil
 }
 
+// OrderedJSON converts the microdata set to JSON like JSON, but writes the
+// properties of each item in document order rather than sorted by name. Like
+// JSON, it returns an error if an item is nested within itself.
+func (m *Microdata) OrderedJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"items":[`)
+	for idx, item := range m.Items {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeOrderedItem(&buf, item, make(map[*Item]bool)); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString(`]}`)
+	return buf.Bytes(), nil
+}
+
+// writeOrderedItem writes an item as OrderedJSON does. ancestors holds the
+// items enclosing it, which it must not contain.
+func writeOrderedItem(buf *bytes.Buffer, item *Item, ancestors map[*Item]bool) error {
+	if ancestors[item] {
+		return fmt.Errorf("microdata: cannot write an item nested within itself")
+	}
+	ancestors[item] = true
+	defer delete(ancestors, item)
+
+	buf.WriteString(`{"properties":{`)
+	for idx, name := range item.PropertyNames() {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSONValue(buf, name); err != nil {
+			return err
+		}
+		buf.WriteString(`:[`)
+		for vidx, value := range item.Properties[name] {
+			if vidx > 0 {
+				buf.WriteByte(',')
+			}
+			if child, ok := value.(*Item); ok {
+				if err := writeOrderedItem(buf, child, ancestors); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writeJSONValue(buf, value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte('}')
+
+	if len(item.Types) > 0 {
+		buf.WriteString(`,"type":`)
+		if err := writeJSONValue(buf, item.Types); err != nil {
+			return err
+		}
+	}
+	if item.ID != "" {
+		buf.WriteString(`,"id":`)
+		if err := writeJSONValue(buf, item.ID); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
 // Parser is an HTML parser that extracts microdata
 type Parser struct {
 	r               io.Reader
 	data            *Microdata
 	base            *url.URL
 	identifiedNodes map[string]*html.Node
+	nodeIndex       map[*html.Node]int
+	positions       map[*Item]map[string][]int
 }
 
 // NewParser creates a new parser for extracting microdata
 // r is a reader over an HTML document
-// base is the base URL for resolving relative URLs
+// base is the base URL for resolving relative URLs, which are left unchanged
+// if it is nil
 func NewParser(r io.Reader, base *url.URL) *Parser {
 	return &Parser{
 		r:    r,
@@ -102,12 +302,20 @@ func (p *Parser) Parse() (*Microdata, error) {
 	if err != nil {
 		return nil, err
 	}
+	p.parseTree(tree)
+	return p.data, nil
+}
 
+func (p *Parser) parseTree(tree *html.Node) {
 	topLevelItemNodes := make([]*html.Node, 0)
 	p.identifiedNodes = make(map[string]*html.Node, 0)
+	p.nodeIndex = make(map[*html.Node]int, 0)
+	p.positions = make(map[*Item]map[string][]int, 0)
 
 	walk(tree, func(n *html.Node) {
 		if n.Type == html.ElementNode {
+			p.nodeIndex[n] = len(p.nodeIndex)
+
 			if _, exists := getAttr("itemscope", n); exists {
 				if _, exists := getAttr("itemprop", n); !exists {
 					topLevelItemNodes = append(topLevelItemNodes, n)
@@ -123,8 +331,47 @@ func (p *Parser) Parse() (*Microdata, error) {
 	for _, node := range topLevelItemNodes {
 		p.data.Items = append(p.data.Items, p.readItem(nil, node))
 	}
+	p.sortProperties()
+}
 
-	return p.data, nil
+// addProperty adds a property value found on node to item, recording the
+// position of node so the item's properties can be sorted into tree order.
+func (p *Parser) addProperty(item *Item, name string, value interface{}, node *html.Node) {
+	item.addValue(name, value)
+
+	positions, exists := p.positions[item]
+	if !exists {
+		positions = make(map[string][]int)
+		p.positions[item] = positions
+	}
+	positions[name] = append(positions[name], p.nodeIndex[node])
+}
+
+// sortProperties puts the property names and values of each item into tree
+// order. Without this, properties found through itemref would precede the
+// item's own properties regardless of where they appear in the document.
+func (p *Parser) sortProperties() {
+	for item, positions := range p.positions {
+		first := make(map[string]int, len(positions))
+
+		for name, pos := range positions {
+			values := item.Properties[name]
+			order := make([]int, len(values))
+			for i := range order {
+				order[i] = i
+			}
+			sort.SliceStable(order, func(a, b int) bool { return pos[order[a]] < pos[order[b]] })
+
+			sorted := make(valueList, len(values))
+			for i, j := range order {
+				sorted[i] = values[j]
+			}
+			item.Properties[name] = sorted
+			first[name] = pos[order[0]]
+		}
+
+		sort.SliceStable(item.names, func(a, b int) bool { return first[item.names[a]] < first[item.names[b]] })
+	}
 }
 
 func (p *Parser) readItem(item *Item, node *html.Node) *Item {
@@ -142,8 +389,8 @@ func (p *Parser) readItem(item *Item, node *html.Node) *Item {
 			}
 			// itemid only valid when itemscope and itemtype are both present
 			if itemid, exists := getAttr("itemid", node); exists {
-				if parsedURL, err := p.base.Parse(itemid); err == nil {
-					item.ID = parsedURL.String()
+				if id, ok := resolveURL(p.base, itemid); ok {
+					item.ID = id
 				}
 			}
 		}
@@ -167,7 +414,7 @@ func (p *Parser) readItem(item *Item, node *html.Node) *Item {
 			for _, propertyName := range strings.Split(strings.TrimSpace(itemprop), " ") {
 				propertyName = strings.TrimSpace(propertyName)
 				if propertyName != "" {
-					parent.AddItem(propertyName, item)
+					p.addProperty(parent, propertyName, item, node)
 				}
 			}
 		} else {
@@ -180,15 +427,11 @@ func (p *Parser) readItem(item *Item, node *html.Node) *Item {
 				}
 			case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
 				if urlValue, exists := getAttr("src", node); exists {
-					if parsedURL, err := p.base.Parse(urlValue); err == nil {
-						propertyValue = parsedURL.String()
-					}
+					propertyValue, _ = resolveURL(p.base, urlValue)
 				}
 			case atom.A, atom.Area, atom.Link:
 				if urlValue, exists := getAttr("href", node); exists {
-					if parsedURL, err := p.base.Parse(urlValue); err == nil {
-						propertyValue = parsedURL.String()
-					}
+					propertyValue, _ = resolveURL(p.base, urlValue)
 				}
 			case atom.Object:
 				if urlValue, exists := getAttr("data", node); exists {
@@ -204,21 +447,14 @@ func (p *Parser) readItem(item *Item, node *html.Node) *Item {
 				}
 
 			default:
-				var text bytes.Buffer
-				walk(node, func(n *html.Node) {
-					if n.Type == html.TextNode {
-						text.WriteString(n.Data)
-					}
-
-				})
-				propertyValue = text.String()
+				propertyValue = textContent(node)
 			}
 
 			if len(propertyValue) > 0 {
 				for _, propertyName := range strings.Split(strings.TrimSpace(itemprop), " ") {
 					propertyName = strings.TrimSpace(propertyName)
 					if propertyName != "" {
-						item.AddString(propertyName, propertyValue)
+						p.addProperty(item, propertyName, propertyValue, node)
 					}
 				}
 			}
@@ -242,6 +478,30 @@ func getAttr(name string, node *html.Node) (string, bool) {
 	return "", false
 }
 
+// resolveURL resolves a URL reference against base. It reports false if the
+// reference cannot be parsed. A nil base leaves the reference unchanged.
+func resolveURL(base *url.URL, ref string) (string, bool) {
+	if base == nil {
+		return ref, true
+	}
+	parsedURL, err := base.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	return parsedURL.String(), true
+}
+
+// textContent returns the concatenated text of all the text nodes under node.
+func textContent(node *html.Node) string {
+	var text bytes.Buffer
+	walk(node, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+	})
+	return text.String()
+}
+
 func walk(parent *html.Node, fn func(n *html.Node)) {
 	if parent == nil {
 		return
@@ -7,6 +7,7 @@ package microdata
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/url"
 	"reflect"
 	"strings"
@@ -664,3 +665,143 @@ func TestPropertiesInContainedItem(t *testing.T) {
 		t.Errorf("expected outer to have a child of author, got %v", outer.Properties["author"])
 	}
 }
+
+func TestUnmarshalJSON(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Person" itemid="http://example.com/amanda">
+			 <p>Name: <span itemprop="name">Amanda</span></p>
+			 <p>Band: <span itemprop="band" itemscope> <span itemprop="name">Jazz Band</span> (<span itemprop="size">12</span> players)</span></p>
+			</div>`
+
+	expected := ParseData(html, t)
+
+	b, err := expected.OrderedJSON()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	actual := NewMicrodata()
+	if err := json.Unmarshal(b, actual); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expecting %v but got %v", expected, actual)
+	}
+
+	if _, ok := actual.Items[0].Properties["band"][0].(*Item); !ok {
+		t.Errorf("Expecting nested item but got %T", actual.Items[0].Properties["band"][0])
+	}
+}
+
+func TestUnmarshalJSONTypedValues(t *testing.T) {
+	b := []byte(`{"items":[{"type":"http://schema.org/Offer","properties":{"price":[12.5],"available":[true],"seller":[{"properties":{"name":["ACME"]}}]}}]}`)
+
+	data := NewMicrodata()
+	if err := json.Unmarshal(b, data); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	item := data.Items[0]
+	if len(item.Types) != 1 || item.Types[0] != "http://schema.org/Offer" {
+		t.Errorf("Expecting type 'http://schema.org/Offer' but got %v", item.Types)
+	}
+	if item.Properties["price"][0].(string) != "12.5" {
+		t.Errorf("Expecting price '12.5' but got %v", item.Properties["price"][0])
+	}
+	if item.Properties["available"][0].(string) != "true" {
+		t.Errorf("Expecting available 'true' but got %v", item.Properties["available"][0])
+	}
+	if item.Properties["seller"][0].(*Item).Properties["name"][0].(string) != "ACME" {
+		t.Errorf("Expecting seller name 'ACME' but got %v", item.Properties["seller"])
+	}
+}
+
+func TestParsePropertiesInTreeOrder(t *testing.T) {
+	html := `<body>
+		<div itemscope itemref="b a"><span itemprop="name">Amanda</span></div>
+		<p id="a">Age: <span itemprop="age">26</span></p>
+		<p id="b">Name: <span itemprop="name">Mandy</span><span itemprop="band">Jazz Band</span></p>
+		</body>`
+
+	item := ParseOneItem(html, t)
+
+	names := item.PropertyNames()
+	expected := []string{"name", "age", "band"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expecting property names %v but got %v", expected, names)
+	}
+
+	if item.Properties["name"][0].(string) != "Amanda" || item.Properties["name"][1].(string) != "Mandy" {
+		t.Errorf("Expecting name values in tree order but got %v", item.Properties["name"])
+	}
+}
+
+func TestOrderedJSON(t *testing.T) {
+	item := NewItem()
+	item.AddType("http://example.org/animals#cat")
+	item.AddString("name", "Elizabeth")
+	item.AddString("color", "Black")
+
+	data := NewMicrodata()
+	data.AddItem(item)
+
+	expected := []byte(`{"items":[{"properties":{"name":["Elizabeth"],"color":["Black"]},"type":["http://example.org/animals#cat"]}]}`)
+
+	actual, _ := data.OrderedJSON()
+
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("Expecting %s but got %s", expected, actual)
+	}
+}
+
+func TestOrderedJSONCycle(t *testing.T) {
+	a, b := NewItem(), NewItem()
+	a.AddString("name", "Alice")
+	b.AddString("name", "Bob")
+	a.AddItem("knows", b)
+	b.AddItem("knows", a)
+
+	data := NewMicrodata()
+	data.AddItem(a)
+
+	if _, err := data.JSON(); err == nil {
+		t.Errorf("Expecting JSON to report the cycle")
+	}
+	if _, err := data.OrderedJSON(); err == nil {
+		t.Errorf("Expecting OrderedJSON to report the cycle")
+	}
+
+	// An item shared by two properties is not a cycle.
+	shared := NewItem()
+	shared.AddString("name", "Carol")
+	c := NewItem()
+	c.AddItem("knows", shared)
+	c.AddItem("follows", shared)
+	data = NewMicrodata()
+	data.AddItem(c)
+	if _, err := data.OrderedJSON(); err != nil {
+		t.Errorf("Expecting no error for a shared item but got %v", err)
+	}
+}
+
+func TestParseWithoutBase(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Thing" itemid="/things/1">
+	  <a itemprop="url" href="/things/1.html">Thing</a>
+	  <img itemprop="image" src="http://example.com/thing.png">
+	</div>`
+
+	data, err := NewParser(strings.NewReader(html), nil).Parse()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	item := data.Items[0]
+	if item.ID != "/things/1" {
+		t.Errorf("Expecting relative id to be unchanged but got %q", item.ID)
+	}
+	if item.Properties["url"][0] != "/things/1.html" {
+		t.Errorf("Expecting relative url to be unchanged but got %v", item.Properties["url"])
+	}
+	if item.Properties["image"][0] != "http://example.com/thing.png" {
+		t.Errorf("Expecting absolute url but got %v", item.Properties["image"])
+	}
+}
@@ -0,0 +1,179 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// NamedGraph is a microdata set together with the IRI of the graph holding its
+// triples, usually the URL of the document the set was parsed from. An empty
+// Name places the triples in the default graph.
+type NamedGraph struct {
+	Name string
+	Data *Microdata
+}
+
+// NQuads writes the triples of each graph, as produced by Microdata.Graph, as
+// N-Quads with the graph's name as the fourth element. Blank node labels are
+// stable and scoped to each graph, so that output written separately for
+// different documents can be concatenated: the nth blank node of a graph is
+// labelled _:gh_bn, where h is taken from a hash of the graph's name and items.
+// An error is returned if the name or an IRI of the triples is not absolute,
+// such as an item ID left relative because there was no base URL.
+func NQuads(graphs ...NamedGraph) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, g := range graphs {
+		if g.Data == nil {
+			continue
+		}
+		triples, err := graphTriples(g)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range triples {
+			buf.WriteString(t.Subject.nTriples())
+			buf.WriteString(" ")
+			buf.WriteString(t.Predicate.nTriples())
+			buf.WriteString(" ")
+			buf.WriteString(t.Object.nTriples())
+			if g.Name != "" {
+				buf.WriteString(" ")
+				buf.WriteString(Term{Kind: IRI, Value: g.Name}.nTriples())
+			}
+			buf.WriteString(" .\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// TriG writes the triples of each graph as a TriG graph block named by the
+// graph's name, grouping the statements about each subject. Triples of graphs
+// without a name are written outside any block, in the default graph. Triples
+// and blank node labels are as described by NQuads.
+func TriG(graphs ...NamedGraph) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, g := range graphs {
+		if g.Data == nil {
+			continue
+		}
+		triples, err := graphTriples(g)
+		if err != nil {
+			return nil, err
+		}
+
+		indent := ""
+		if g.Name != "" {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString(Term{Kind: IRI, Value: g.Name}.nTriples())
+			buf.WriteString(" {\n")
+			indent = "  "
+		}
+
+		subjects, bySubject := groupBySubject(triples)
+
+		for _, s := range subjects {
+			buf.WriteString(indent)
+			buf.WriteString(s.nTriples())
+			for i, t := range bySubject[s] {
+				if i > 0 {
+					buf.WriteString(" ;\n")
+					buf.WriteString(indent + "    ")
+				} else {
+					buf.WriteString(" ")
+				}
+				if t.Predicate.Value == rdfType {
+					buf.WriteString("a")
+				} else {
+					buf.WriteString(t.Predicate.nTriples())
+				}
+				buf.WriteString(" ")
+				buf.WriteString(t.Object.nTriples())
+			}
+			buf.WriteString(" .\n")
+		}
+
+		if g.Name != "" {
+			buf.WriteString("}\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// graphTriples returns the triples of a graph with its blank node labels, and
+// checks that they and the graph's name can be written.
+func graphTriples(g NamedGraph) ([]Triple, error) {
+	if g.Name != "" && !isAbsoluteIRI(g.Name) {
+		return nil, fmt.Errorf("microdata: cannot write relative graph name %q", g.Name)
+	}
+
+	h := sha256.New()
+	io.WriteString(h, g.Name)
+	for _, item := range g.Data.Items {
+		io.WriteString(h, "\n"+item.Hash())
+	}
+	triples := microdataTriples(g.Data, "g"+hex.EncodeToString(h.Sum(nil))[:12]+"_b")
+
+	for _, t := range triples {
+		for _, term := range []Term{t.Subject, t.Predicate, t.Object} {
+			if term.Kind == IRI && !isAbsoluteIRI(term.Value) {
+				return nil, fmt.Errorf("microdata: cannot write relative IRI %q", term.Value)
+			}
+		}
+	}
+	return triples, nil
+}
+
+// isAbsoluteIRI reports whether s is an IRI with a scheme.
+func isAbsoluteIRI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+// nTriples returns the Term in N-Triples syntax, which TriG and N-Quads share.
+func (t Term) nTriples() string {
+	switch t.Kind {
+	case Blank:
+		return "_:" + t.Value
+	case Literal:
+		literal := "\"" + escapeLiteral(t.Value) + "\""
+		if t.Language != "" {
+			return literal + "@" + t.Language
+		}
+		if t.Datatype != "" {
+			return literal + "^^<" + escapeIRI(t.Datatype) + ">"
+		}
+		return literal
+	}
+	return "<" + escapeIRI(t.Value) + ">"
+}
+
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func escapeLiteral(s string) string {
+	return literalEscaper.Replace(s)
+}
+
+// escapeIRI escapes the characters that may not appear in an IRI reference.
+func escapeIRI(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		if r <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&buf, "\\u%04X", r)
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,127 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"strings"
+	"testing"
+)
+
+const nquadsHTML = `<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+  <span itemprop="name">Widget "Deluxe"</span>
+  <link itemprop="http://purl.org/dc/terms/subject" href="http://example.com/tools">
+  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+    <span itemprop="price">12.50</span>
+    <link itemprop="availability" href="http://schema.org/InStock">
+  </div>
+</div>
+<div itemscope><span itemprop="note">Untyped</span></div>`
+
+func TestNQuads(t *testing.T) {
+	data := ParseData(nquadsHTML, t)
+
+	b, err := NQuads(NamedGraph{Name: "http://example.com/page", Data: data}, NamedGraph{Data: ParseData(`<div itemscope><span itemprop="note">Default</span></div>`, t)})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `<http://example.com/widget> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Product> <http://example.com/page> .
+<http://example.com/widget> <http://schema.org/name> "Widget \"Deluxe\"" <http://example.com/page> .
+<http://example.com/widget> <http://purl.org/dc/terms/subject> <http://example.com/tools> <http://example.com/page> .
+_:gaa1cd273109b_b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Offer> <http://example.com/page> .
+_:gaa1cd273109b_b0 <http://schema.org/price> "12.50" <http://example.com/page> .
+_:gaa1cd273109b_b0 <http://schema.org/availability> <http://schema.org/InStock> <http://example.com/page> .
+<http://example.com/widget> <http://schema.org/offers> _:gaa1cd273109b_b0 <http://example.com/page> .
+_:gaa1cd273109b_b1 <http://www.w3.org/1999/xhtml/microdata#note> "Untyped" <http://example.com/page> .
+_:g1304fb2fea1f_b0 <http://www.w3.org/1999/xhtml/microdata#note> "Default" .
+`
+	if string(b) != expected {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestTriG(t *testing.T) {
+	data := ParseData(nquadsHTML, t)
+
+	b, err := TriG(NamedGraph{Name: "http://example.com/page", Data: data})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `<http://example.com/page> {
+  <http://example.com/widget> a <http://schema.org/Product> ;
+      <http://schema.org/name> "Widget \"Deluxe\"" ;
+      <http://purl.org/dc/terms/subject> <http://example.com/tools> ;
+      <http://schema.org/offers> _:gaa1cd273109b_b0 .
+  _:gaa1cd273109b_b0 a <http://schema.org/Offer> ;
+      <http://schema.org/price> "12.50" ;
+      <http://schema.org/availability> <http://schema.org/InStock> .
+  _:gaa1cd273109b_b1 <http://www.w3.org/1999/xhtml/microdata#note> "Untyped" .
+}
+`
+	if string(b) != expected {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestNQuadsSharedAndCyclicItems(t *testing.T) {
+	a, b := NewItem(), NewItem()
+	a.AddType("http://schema.org/Person")
+	a.AddItem("knows", b)
+	b.AddItem("knows", a)
+	b.AddString("name", "B\nC")
+
+	data := NewMicrodata()
+	data.AddItem(a)
+	data.AddItem(b)
+
+	out, err := NQuads(NamedGraph{Data: data})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `_:g383ba77ac58b_b0 <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Person> .
+_:g383ba77ac58b_b1 <http://schema.org/knows> _:g383ba77ac58b_b0 .
+_:g383ba77ac58b_b1 <http://schema.org/name> "B\nC" .
+_:g383ba77ac58b_b0 <http://schema.org/knows> _:g383ba77ac58b_b1 .
+`
+	if string(out) != expected {
+		t.Errorf("Expecting %s but got %s", expected, out)
+	}
+}
+
+func TestNQuadsRelativeIRIs(t *testing.T) {
+	data, err := NewParser(strings.NewReader(`<div itemscope itemtype="http://schema.org/Thing" itemid="/things/1"></div>`), nil).Parse()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if _, err := NQuads(NamedGraph{Data: data}); err == nil {
+		t.Errorf("Expecting an error for a relative item id")
+	}
+	if _, err := TriG(NamedGraph{Data: data}); err == nil {
+		t.Errorf("Expecting an error for a relative item id in TriG")
+	}
+	if _, err := NQuads(NamedGraph{Name: "page", Data: NewMicrodata()}); err == nil {
+		t.Errorf("Expecting an error for a relative graph name")
+	}
+}
+
+func TestNQuadsLabelsDifferByDocument(t *testing.T) {
+	a, err := NQuads(NamedGraph{Name: "http://example.com/a", Data: ParseData(`<div itemscope><span itemprop="note">A</span></div>`, t)})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	b, err := NQuads(NamedGraph{Name: "http://example.com/b", Data: ParseData(`<div itemscope><span itemprop="note">B</span></div>`, t)})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	label := func(quad []byte) string { return strings.Fields(string(quad))[0] }
+	if label(a) == label(b) {
+		t.Errorf("Expecting blank node labels of different documents to differ but both are %s", label(a))
+	}
+}
@@ -0,0 +1,282 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespaces used when converting items to RDF.
+const (
+	// MicrodataNS is the vocabulary of properties of items without a type
+	// that are not nested in a typed item.
+	MicrodataNS = "http://www.w3.org/1999/xhtml/microdata#"
+
+	rdfNS   = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rdfType = rdfNS + "type"
+)
+
+// TermKind is the kind of an RDF term.
+type TermKind int
+
+// Kinds of RDF term.
+const (
+	IRI TermKind = iota
+	Blank
+	Literal
+)
+
+// Term is an IRI, blank node or literal. The Value of a blank node is its
+// label without the _: prefix. Literals produced from items are plain strings;
+// Datatype and Language may be set on literals from other sources.
+type Term struct {
+	Kind     TermKind
+	Value    string
+	Datatype string
+	Language string
+}
+
+// Triple is an RDF statement.
+type Triple struct {
+	Subject, Predicate, Object Term
+}
+
+// Graph is a set of RDF triples, in the order they were produced.
+type Graph struct {
+	Triples []Triple
+}
+
+// Graph converts the items into RDF triples. Items with an ID are IRIs and
+// others are blank nodes labelled b0, b1 and so on in the order they are
+// found. Types are rdf:type statements, and property names that are not
+// absolute URLs are taken to be in the vocabulary of the first type of the
+// item or of its nearest typed ancestor, or in MicrodataNS if there is none.
+// The vocabulary of a type is the type up to its last # or /, such as
+// http://schema.org/ for http://schema.org/Person. String values that are
+// absolute URLs are IRIs and others are plain literals. Items holding a
+// literal with a language or datatype, as made by Graph.Microdata, are
+// literals again.
+func (m *Microdata) Graph() *Graph {
+	return &Graph{Triples: microdataTriples(m, "b")}
+}
+
+// tripleWriter converts items into triples as described by Microdata.Graph.
+type tripleWriter struct {
+	blankPrefix string
+	subjects    map[*Item]Term
+	blanks      int
+	triples     []Triple
+}
+
+// microdataTriples returns the triples of the items in m, labelling blank
+// nodes with blankPrefix followed by a number in the order they are found.
+func microdataTriples(m *Microdata, blankPrefix string) []Triple {
+	w := &tripleWriter{blankPrefix: blankPrefix, subjects: make(map[*Item]Term)}
+	for _, item := range m.Items {
+		w.item(item, MicrodataNS)
+	}
+	return w.triples
+}
+
+func (w *tripleWriter) item(item *Item, vocab string) Term {
+	if subject, exists := w.subjects[item]; exists {
+		return subject
+	}
+
+	subject := Term{Kind: IRI, Value: item.ID}
+	if item.ID == "" {
+		subject = Term{Kind: Blank, Value: fmt.Sprintf("%s%d", w.blankPrefix, w.blanks)}
+		w.blanks++
+	}
+	w.subjects[item] = subject
+
+	for _, t := range item.Types {
+		w.triples = append(w.triples, Triple{subject, Term{Kind: IRI, Value: rdfType}, Term{Kind: IRI, Value: t}})
+	}
+	if len(item.Types) > 0 {
+		vocab = typeVocabulary(item.Types[0])
+	}
+
+	for _, name := range item.PropertyNames() {
+		predicate := Term{Kind: IRI, Value: name}
+		if !isAbsoluteURL(name) {
+			predicate.Value = vocab + name
+		}
+		for _, v := range item.Properties[name] {
+			switch tv := v.(type) {
+			case string:
+				object := Term{Kind: Literal, Value: tv}
+				if isAbsoluteURL(tv) {
+					object.Kind = IRI
+				}
+				w.triples = append(w.triples, Triple{subject, predicate, object})
+			case *Item:
+				if object, ok := literalTerm(tv); ok {
+					w.triples = append(w.triples, Triple{subject, predicate, object})
+					continue
+				}
+				w.triples = append(w.triples, Triple{subject, predicate, w.item(tv, vocab)})
+			}
+		}
+	}
+	return subject
+}
+
+// literalItem returns an item holding a literal with a language or datatype,
+// in the form of a JSON-LD value object: an @value property with @language or
+// @type beside it.
+func literalItem(t Term) *Item {
+	item := NewItem()
+	item.AddString("@value", t.Value)
+	if t.Language != "" {
+		item.AddString("@language", t.Language)
+	} else {
+		item.AddString("@type", t.Datatype)
+	}
+	return item
+}
+
+// literalTerm returns the literal held by an item made by literalItem. It
+// reports false for any other item.
+func literalTerm(item *Item) (Term, bool) {
+	if item.ID != "" || len(item.Types) > 0 || len(item.Properties) != 2 {
+		return Term{}, false
+	}
+	single := func(name string) (string, bool) {
+		values := item.Properties[name]
+		if len(values) != 1 {
+			return "", false
+		}
+		s, ok := values[0].(string)
+		return s, ok
+	}
+
+	value, ok := single("@value")
+	if !ok {
+		return Term{}, false
+	}
+	if language, ok := single("@language"); ok && language != "" {
+		return Term{Kind: Literal, Value: value, Language: language}, true
+	}
+	if datatype, ok := single("@type"); ok && datatype != "" {
+		return Term{Kind: Literal, Value: value, Datatype: datatype}, true
+	}
+	return Term{}, false
+}
+
+// typeVocabulary returns the vocabulary of an item type: the type up to and
+// including its last # or, failing that, its last /.
+func typeVocabulary(t string) string {
+	if i := strings.LastIndex(t, "#"); i >= 0 {
+		return t[:i+1]
+	}
+	if i := strings.LastIndex(t, "/"); i >= 0 {
+		return t[:i+1]
+	}
+	return t
+}
+
+// Microdata rebuilds items from the graph. Each subject becomes an item, with
+// an IRI subject as its ID and the IRI objects of its rdf:type statements as
+// its types. Subjects that are not the object of any statement are top-level
+// items, followed by any not reachable from them, and the others are nested
+// where they are objects. Predicates in the vocabulary of the item's type,
+// or that of its nearest typed ancestor, become short property names as
+// described by Microdata.Graph and others keep the full IRI. Objects that are
+// not subjects become string values, apart from blank nodes which become
+// empty items and literals with a language or datatype, which become items
+// with @value and @language or @type properties so that they are not lost.
+// The items of a set converted by Microdata.Graph are rebuilt with the same
+// properties, except that top-level items also nested within another item are
+// only nested.
+func (g *Graph) Microdata() *Microdata {
+	subjects, statements := groupBySubject(g.Triples)
+	r := &graphReader{statements: statements, items: make(map[Term]*Item)}
+
+	referenced := make(map[Term]bool)
+	for _, t := range g.Triples {
+		if _, isSubject := r.statements[t.Object]; isSubject && t.Object.Kind != Literal && t.Object != t.Subject {
+			referenced[t.Object] = true
+		}
+	}
+
+	m := NewMicrodata()
+	for _, s := range subjects {
+		if !referenced[s] {
+			m.AddItem(r.item(s, MicrodataNS))
+		}
+	}
+	for _, s := range subjects {
+		if _, built := r.items[s]; !built {
+			m.AddItem(r.item(s, MicrodataNS))
+		}
+	}
+	return m
+}
+
+type graphReader struct {
+	statements map[Term][]Triple
+	items      map[Term]*Item
+}
+
+func (r *graphReader) item(subject Term, vocab string) *Item {
+	if item, exists := r.items[subject]; exists {
+		return item
+	}
+
+	item := NewItem()
+	if subject.Kind == IRI {
+		item.ID = subject.Value
+	}
+	r.items[subject] = item
+
+	for _, t := range r.statements[subject] {
+		if t.Predicate.Value == rdfType && t.Object.Kind == IRI {
+			item.AddType(t.Object.Value)
+		}
+	}
+	if len(item.Types) > 0 {
+		vocab = typeVocabulary(item.Types[0])
+	}
+
+	for _, t := range r.statements[subject] {
+		if t.Predicate.Value == rdfType && t.Object.Kind == IRI {
+			continue
+		}
+		name := t.Predicate.Value
+		if local := strings.TrimPrefix(name, vocab); local != name && local != "" && !strings.ContainsAny(local, "/#:") {
+			name = local
+		}
+
+		_, isSubject := r.statements[t.Object]
+		switch {
+		case t.Object.Kind == Literal && (t.Object.Language != "" || t.Object.Datatype != ""):
+			item.AddItem(name, literalItem(t.Object))
+		case t.Object.Kind == Literal:
+			item.AddString(name, t.Object.Value)
+		case isSubject, t.Object.Kind == Blank:
+			item.AddItem(name, r.item(t.Object, vocab))
+		default:
+			item.AddString(name, t.Object.Value)
+		}
+	}
+	return item
+}
+
+// groupBySubject returns the subjects of triples in the order they are first
+// found, and the triples about each one.
+func groupBySubject(triples []Triple) ([]Term, map[Term][]Triple) {
+	subjects := make([]Term, 0)
+	bySubject := make(map[Term][]Triple)
+	for _, t := range triples {
+		if _, exists := bySubject[t.Subject]; !exists {
+			subjects = append(subjects, t.Subject)
+		}
+		bySubject[t.Subject] = append(bySubject[t.Subject], t)
+	}
+	return subjects, bySubject
+}
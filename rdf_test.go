@@ -0,0 +1,106 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Person" itemid="http://example.com/jane">
+	  <span itemprop="name">Jane</span>
+	  <div itemprop="address" itemscope><span itemprop="postalCode">12345</span></div>
+	</div>`
+
+	g := ParseData(html, t).Graph()
+	expected := []Triple{
+		{Term{Kind: IRI, Value: "http://example.com/jane"}, Term{Kind: IRI, Value: "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"}, Term{Kind: IRI, Value: "http://schema.org/Person"}},
+		{Term{Kind: IRI, Value: "http://example.com/jane"}, Term{Kind: IRI, Value: "http://schema.org/name"}, Term{Kind: Literal, Value: "Jane"}},
+		{Term{Kind: Blank, Value: "b0"}, Term{Kind: IRI, Value: "http://schema.org/postalCode"}, Term{Kind: Literal, Value: "12345"}},
+		{Term{Kind: IRI, Value: "http://example.com/jane"}, Term{Kind: IRI, Value: "http://schema.org/address"}, Term{Kind: Blank, Value: "b0"}},
+	}
+	if !reflect.DeepEqual(g.Triples, expected) {
+		t.Errorf("Expecting %v but got %v", expected, g.Triples)
+	}
+}
+
+func TestGraphMicrodataRoundTrip(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product http://example.org/Gadget" itemid="http://example.com/widget">
+	  <span itemprop="name">Widget</span>
+	  <span itemprop="name">Gizmo</span>
+	  <link itemprop="http://purl.org/dc/terms/subject" href="http://example.com/tools">
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+	    <span itemprop="price">12.50</span>
+	    <div itemprop="seller" itemscope><span itemprop="name">Acme</span></div>
+	  </div>
+	</div>
+	<div itemscope><span itemprop="note">Untyped</span></div>`
+
+	original := ParseData(html, t)
+	rebuilt := original.Graph().Microdata()
+
+	if len(rebuilt.Items) != len(original.Items) {
+		t.Fatalf("Expecting %d items but got %d", len(original.Items), len(rebuilt.Items))
+	}
+	for i := range original.Items {
+		if !rebuilt.Items[i].Equal(original.Items[i]) {
+			t.Errorf("Expecting item %d to round trip but got %v", i, rebuilt.Items[i])
+		}
+	}
+}
+
+func TestGraphMicrodataLiterals(t *testing.T) {
+	subject := Term{Kind: IRI, Value: "http://example.com/book"}
+	g := &Graph{Triples: []Triple{
+		{subject, Term{Kind: IRI, Value: rdfType}, Term{Kind: IRI, Value: "http://schema.org/Book"}},
+		{subject, Term{Kind: IRI, Value: "http://schema.org/name"}, Term{Kind: Literal, Value: "Le Livre", Language: "fr"}},
+		{subject, Term{Kind: IRI, Value: "http://schema.org/numberOfPages"}, Term{Kind: Literal, Value: "320", Datatype: "http://www.w3.org/2001/XMLSchema#integer"}},
+		{subject, Term{Kind: IRI, Value: "http://schema.org/description"}, Term{Kind: Literal, Value: "Plain"}},
+	}}
+
+	data := g.Microdata()
+	name, ok := data.Items[0].Properties["name"][0].(*Item)
+	if !ok || name.Properties["@value"][0] != "Le Livre" || name.Properties["@language"][0] != "fr" {
+		t.Errorf("Expecting name to keep its language but got %v", data.Items[0].Properties["name"])
+	}
+
+	rebuilt := data.Graph()
+	if !reflect.DeepEqual(rebuilt.Triples, g.Triples) {
+		t.Errorf("Expecting %v but got %v", g.Triples, rebuilt.Triples)
+	}
+}
+
+func TestGraphMicrodataSharedItems(t *testing.T) {
+	jane := Term{Kind: IRI, Value: "http://example.com/jane"}
+	bob := Term{Kind: Blank, Value: "x"}
+	knows := Term{Kind: IRI, Value: "http://xmlns.com/foaf/0.1/knows"}
+	g := &Graph{Triples: []Triple{
+		{jane, Term{Kind: IRI, Value: rdfType}, Term{Kind: IRI, Value: "http://xmlns.com/foaf/0.1/Person"}},
+		{jane, knows, bob},
+		{bob, knows, jane},
+		{bob, Term{Kind: IRI, Value: "http://xmlns.com/foaf/0.1/name"}, Term{Kind: Literal, Value: "Bob", Language: "en"}},
+		{jane, Term{Kind: IRI, Value: "http://xmlns.com/foaf/0.1/homepage"}, Term{Kind: IRI, Value: "http://jane.example.com/"}},
+	}}
+
+	m := g.Microdata()
+	if len(m.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(m.Items))
+	}
+
+	item := m.Items[0]
+	if item.ID != "http://example.com/jane" || item.Properties["homepage"][0].(string) != "http://jane.example.com/" {
+		t.Errorf("Expecting jane with a homepage but got %v", item)
+	}
+	friend := item.Properties["knows"][0].(*Item)
+	name := itemValue(friend, "name")
+	if friend.ID != "" || name == nil || stringValue(name, "@value") != "Bob" || stringValue(name, "@language") != "en" {
+		t.Errorf("Expecting blank node named Bob in English but got %v", friend)
+	}
+	if friend.Properties["knows"][0] != item {
+		t.Errorf("Expecting cycle to refer back to the same item")
+	}
+}
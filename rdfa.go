@@ -0,0 +1,179 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// rdfaPrefixes are the prefixes of the RDFa initial context that are commonly
+// used without being declared.
+var rdfaPrefixes = map[string]string{
+	"schema":  "http://schema.org/",
+	"og":      "http://ogp.me/ns#",
+	"dc":      "http://purl.org/dc/terms/",
+	"dcterms": "http://purl.org/dc/terms/",
+	"foaf":    "http://xmlns.com/foaf/0.1/",
+	"rdf":     "http://www.w3.org/1999/02/22-rdf-syntax-ns#",
+	"rdfs":    "http://www.w3.org/2000/01/rdf-schema#",
+	"xsd":     "http://www.w3.org/2001/XMLSchema#",
+	"skos":    "http://www.w3.org/2004/02/skos/core#",
+	"sioc":    "http://rdfs.org/sioc/ns#",
+}
+
+// RDFaParser is an HTML parser that extracts RDFa Lite as microdata items
+type RDFaParser struct {
+	r         io.Reader
+	data      *Microdata
+	base      *url.URL
+	resources map[string]*Item
+	refs      []pendingRef
+}
+
+// NewRDFaParser creates a new parser for extracting RDFa Lite
+// r is a reader over an HTML document
+// base is the base URL for resolving relative URLs
+func NewRDFaParser(r io.Reader, base *url.URL) *RDFaParser {
+	return &RDFaParser{
+		r:    r,
+		data: NewMicrodata(),
+		base: base,
+	}
+}
+
+// Parse the document and return a Microdata set. Elements with a typeof
+// attribute become items typed with the expanded types, and take their ID from
+// a resource, href or src attribute. The property attribute adds values to the
+// nearest enclosing item: the item started on the same element if it has a
+// typeof, otherwise the value of a content, resource, href or src attribute,
+// the datetime of a time element, or the element's text. Terms are expanded
+// using the vocab and prefix attributes in scope; properties in the current
+// vocabulary keep their short names, as they would in microdata. A property
+// whose value is the resource of an item elsewhere in the document refers to
+// that item.
+func (p *RDFaParser) Parse() (*Microdata, error) {
+	tree, err := html.Parse(p.r)
+	if err != nil {
+		return nil, err
+	}
+	p.parseTree(tree)
+	return p.data, nil
+}
+
+func (p *RDFaParser) parseTree(tree *html.Node) {
+	p.resources = make(map[string]*Item)
+	p.refs = nil
+
+	ctx := &vocabContext{prefixes: make(map[string]string, len(rdfaPrefixes))}
+	for prefix, iri := range rdfaPrefixes {
+		ctx.prefixes[prefix] = iri
+	}
+	p.readNode(nil, tree, ctx)
+	linkRefs(p.refs, p.resources)
+}
+
+func (p *RDFaParser) readNode(item *Item, node *html.Node, ctx *vocabContext) {
+	if node.Type == html.ElementNode {
+		ctx = p.scope(node, ctx)
+
+		property, hasProperty := getAttr("property", node)
+		typeof, hasTypeof := getAttr("typeof", node)
+
+		if hasTypeof {
+			child := NewItem()
+			for _, t := range strings.Fields(typeof) {
+				child.AddType(ctx.expand(t))
+			}
+			if id, ok := p.resource(node); ok {
+				child.ID = id
+				p.resources[id] = child
+			}
+
+			if hasProperty && item != nil {
+				for _, name := range strings.Fields(property) {
+					item.AddItem(ctx.property(name), child)
+				}
+			} else {
+				p.data.AddItem(child)
+			}
+			item = child
+		} else if hasProperty && item != nil {
+			value, isRef := p.propertyValue(node)
+			for _, name := range strings.Fields(property) {
+				name = ctx.property(name)
+				item.AddString(name, value)
+				if isRef {
+					p.refs = append(p.refs, pendingRef{item: item, name: name, index: len(item.Properties[name]) - 1, id: value})
+				}
+			}
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		p.readNode(item, child, ctx)
+	}
+}
+
+// scope returns the context in effect for node, updated by its vocab and
+// prefix attributes.
+func (p *RDFaParser) scope(node *html.Node, ctx *vocabContext) *vocabContext {
+	vocab, hasVocab := getAttr("vocab", node)
+	prefix, hasPrefix := getAttr("prefix", node)
+	if !hasVocab && !hasPrefix {
+		return ctx
+	}
+
+	nc := &vocabContext{vocab: ctx.vocab, prefixes: make(map[string]string, len(ctx.prefixes))}
+	for k, v := range ctx.prefixes {
+		nc.prefixes[k] = v
+	}
+	if hasVocab {
+		nc.vocab = strings.TrimSpace(vocab)
+	}
+
+	// prefix holds pairs such as "og: http://ogp.me/ns#"
+	fields := strings.Fields(prefix)
+	for i := 0; i+1 < len(fields); i++ {
+		if name := fields[i]; strings.HasSuffix(name, ":") && len(name) > 1 {
+			nc.prefixes[name[:len(name)-1]] = fields[i+1]
+			i++
+		}
+	}
+	return nc
+}
+
+// resource returns the resolved IRI given by a node's resource, href or src
+// attribute.
+func (p *RDFaParser) resource(node *html.Node) (string, bool) {
+	for _, name := range []string{"resource", "href", "src"} {
+		if ref, exists := getAttr(name, node); exists {
+			return resolveURL(p.base, ref)
+		}
+	}
+	return "", false
+}
+
+// propertyValue returns the value of a property on a node without typeof and
+// reports whether it is an IRI that may refer to an item.
+func (p *RDFaParser) propertyValue(node *html.Node) (string, bool) {
+	if content, exists := getAttr("content", node); exists {
+		return content, false
+	}
+	if id, ok := p.resource(node); ok {
+		return id, true
+	}
+	if node.DataAtom == atom.Time {
+		if datetime, exists := getAttr("datetime", node); exists {
+			return datetime, false
+		}
+	}
+	return textContent(node), false
+}
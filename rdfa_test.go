@@ -0,0 +1,113 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func ParseRDFaData(html string, t *testing.T) *Microdata {
+	u, _ := url.Parse("http://example.com/")
+	data, err := NewRDFaParser(strings.NewReader(html), u).Parse()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return data
+}
+
+func TestRDFaItem(t *testing.T) {
+	html := `<div vocab="http://schema.org/" typeof="Product" resource="/products/1">
+	  <span property="name">Widget</span>
+	  <img property="image" src="widget.png">
+	  <meta property="sku" content="W-1">
+	  <div property="offers" typeof="Offer">
+	    <span property="price">12.50</span>
+	    <link property="availability" href="http://schema.org/InStock">
+	    <time property="validFrom" datetime="2020-01-01">New year</time>
+	  </div>
+	</div>`
+
+	data := ParseRDFaData(html, t)
+	if len(data.Items) != 1 {
+		t.Fatalf("Expecting 1 item but got %d", len(data.Items))
+	}
+
+	item := data.Items[0]
+	if item.Types[0] != "http://schema.org/Product" {
+		t.Errorf("Expecting type http://schema.org/Product but got %s", item.Types[0])
+	}
+	if item.ID != "http://example.com/products/1" {
+		t.Errorf("Expecting id http://example.com/products/1 but got %s", item.ID)
+	}
+	if item.Properties["name"][0].(string) != "Widget" {
+		t.Errorf("Expecting name Widget but got %v", item.Properties["name"][0])
+	}
+	if item.Properties["image"][0].(string) != "http://example.com/widget.png" {
+		t.Errorf("Expecting image http://example.com/widget.png but got %v", item.Properties["image"][0])
+	}
+	if item.Properties["sku"][0].(string) != "W-1" {
+		t.Errorf("Expecting sku W-1 but got %v", item.Properties["sku"][0])
+	}
+	if names := strings.Join(item.PropertyNames(), ","); names != "name,image,sku,offers" {
+		t.Errorf("Expecting properties name,image,sku,offers but got %s", names)
+	}
+
+	offer := item.Properties["offers"][0].(*Item)
+	if offer.Types[0] != "http://schema.org/Offer" {
+		t.Errorf("Expecting type http://schema.org/Offer but got %s", offer.Types[0])
+	}
+	if offer.Properties["availability"][0].(string) != "http://schema.org/InStock" {
+		t.Errorf("Expecting availability http://schema.org/InStock but got %v", offer.Properties["availability"][0])
+	}
+	if offer.Properties["validFrom"][0].(string) != "2020-01-01" {
+		t.Errorf("Expecting validFrom 2020-01-01 but got %v", offer.Properties["validFrom"][0])
+	}
+}
+
+func TestRDFaPrefixes(t *testing.T) {
+	html := `<div vocab="http://schema.org/" prefix="ex: http://example.org/ns#">
+	  <div typeof="Person ex:Author" resource="#jane">
+	    <span property="name">Jane</span>
+	    <span property="ex:nickname">JJ</span>
+	    <span property="og:title">Jane's page</span>
+	  </div>
+	  <div typeof="Book">
+	    <span property="name">Go</span>
+	    <a property="author" href="#jane">Jane</a>
+	  </div>
+	</div>`
+
+	data := ParseRDFaData(html, t)
+	if len(data.Items) != 2 {
+		t.Fatalf("Expecting 2 items but got %d", len(data.Items))
+	}
+
+	person, book := data.Items[0], data.Items[1]
+	if len(person.Types) != 2 || person.Types[1] != "http://example.org/ns#Author" {
+		t.Errorf("Expecting types Person and http://example.org/ns#Author but got %v", person.Types)
+	}
+	if person.Properties["http://example.org/ns#nickname"][0].(string) != "JJ" {
+		t.Errorf("Expecting prefixed property to be expanded but got %v", person.Properties)
+	}
+	if person.Properties["http://ogp.me/ns#title"][0].(string) != "Jane's page" {
+		t.Errorf("Expecting og prefix from the initial context but got %v", person.Properties)
+	}
+	if book.Properties["author"][0] != person {
+		t.Errorf("Expecting author to be the person item but got %v", book.Properties["author"][0])
+	}
+}
+
+func TestRDFaIgnoresMicrodata(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Thing"><span itemprop="name">Thing</span></div>
+	<span property="name">Orphan</span>`
+
+	data := ParseRDFaData(html, t)
+	if len(data.Items) != 0 {
+		t.Errorf("Expecting no items but got %d", len(data.Items))
+	}
+}
@@ -0,0 +1,109 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"unicode"
+)
+
+// RDFXML writes the graph as RDF/XML with an rdf:Description element for each
+// subject. Each predicate is written as an element in its own default
+// namespace, so the predicate must end in a name that is valid as an XML
+// element name. An error is returned for predicates that do not, such as
+// http://example.com/2020.
+func (g *Graph) RDFXML() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<rdf:RDF xmlns:rdf=\"" + rdfNS + "\">\n")
+
+	subjects, bySubject := groupBySubject(g.Triples)
+	for _, s := range subjects {
+		buf.WriteString("  <rdf:Description")
+		writeNodeAttr(&buf, "rdf:about", s)
+		buf.WriteString(">\n")
+
+		for _, t := range bySubject[s] {
+			if t.Predicate.Value == rdfType && t.Object.Kind == IRI {
+				buf.WriteString("    <rdf:type")
+				writeNodeAttr(&buf, "rdf:resource", t.Object)
+				buf.WriteString("/>\n")
+				continue
+			}
+
+			ns, local, ok := splitIRI(t.Predicate.Value)
+			if !ok {
+				return nil, fmt.Errorf("microdata: cannot write predicate %s as an RDF/XML element name", t.Predicate.Value)
+			}
+			buf.WriteString("    <" + local)
+			writeXMLAttr(&buf, "xmlns", ns)
+
+			if t.Object.Kind != Literal {
+				writeNodeAttr(&buf, "rdf:resource", t.Object)
+				buf.WriteString("/>\n")
+				continue
+			}
+			if t.Object.Language != "" {
+				writeXMLAttr(&buf, "xml:lang", t.Object.Language)
+			} else if t.Object.Datatype != "" {
+				writeXMLAttr(&buf, "rdf:datatype", t.Object.Datatype)
+			}
+			buf.WriteString(">")
+			if err := xml.EscapeText(&buf, []byte(t.Object.Value)); err != nil {
+				return nil, err
+			}
+			buf.WriteString("</" + local + ">\n")
+		}
+
+		buf.WriteString("  </rdf:Description>\n")
+	}
+
+	buf.WriteString("</rdf:RDF>\n")
+	return buf.Bytes(), nil
+}
+
+// writeNodeAttr writes an attribute referring to an IRI or, using rdf:nodeID
+// in place of name, to a blank node.
+func writeNodeAttr(buf *bytes.Buffer, name string, t Term) {
+	if t.Kind == Blank {
+		writeXMLAttr(buf, "rdf:nodeID", t.Value)
+		return
+	}
+	writeXMLAttr(buf, name, t.Value)
+}
+
+func writeXMLAttr(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(" " + name + "=\"")
+	xml.EscapeText(buf, []byte(value))
+	buf.WriteString("\"")
+}
+
+// splitIRI splits an IRI into a namespace and the longest suffix that is a
+// valid XML element name.
+func splitIRI(iri string) (ns, local string, ok bool) {
+	runes := []rune(iri)
+	start := len(runes)
+	for start > 0 && isNameChar(runes[start-1]) {
+		start--
+	}
+	for start < len(runes) && !isNameStartChar(runes[start]) {
+		start++
+	}
+	if start == 0 || start == len(runes) {
+		return "", "", false
+	}
+	return string(runes[:start]), string(runes[start:]), true
+}
+
+func isNameStartChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isNameChar(r rune) bool {
+	return isNameStartChar(r) || r == '-' || r == '.' || unicode.IsDigit(r)
+}
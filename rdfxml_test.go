@@ -0,0 +1,70 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"testing"
+)
+
+func TestRDFXML(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+	  <span itemprop="name">Widget &amp; Co</span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+	    <link itemprop="availability" href="http://schema.org/InStock">
+	  </div>
+	</div>`
+
+	b, err := ParseData(html, t).Graph().RDFXML()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="http://example.com/widget">
+    <rdf:type rdf:resource="http://schema.org/Product"/>
+    <name xmlns="http://schema.org/">Widget &amp; Co</name>
+    <offers xmlns="http://schema.org/" rdf:nodeID="b0"/>
+  </rdf:Description>
+  <rdf:Description rdf:nodeID="b0">
+    <rdf:type rdf:resource="http://schema.org/Offer"/>
+    <availability xmlns="http://schema.org/" rdf:resource="http://schema.org/InStock"/>
+  </rdf:Description>
+</rdf:RDF>
+`
+	if string(b) != expected {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+}
+
+func TestRDFXMLLiterals(t *testing.T) {
+	s := Term{Kind: IRI, Value: "http://example.com/a"}
+	g := &Graph{Triples: []Triple{
+		{s, Term{Kind: IRI, Value: "http://purl.org/dc/terms/title"}, Term{Kind: Literal, Value: "Titre", Language: "fr"}},
+		{s, Term{Kind: IRI, Value: "http://purl.org/dc/terms/date"}, Term{Kind: Literal, Value: "2020-01-01", Datatype: "http://www.w3.org/2001/XMLSchema#date"}},
+	}}
+
+	b, err := g.RDFXML()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="http://example.com/a">
+    <title xmlns="http://purl.org/dc/terms/" xml:lang="fr">Titre</title>
+    <date xmlns="http://purl.org/dc/terms/" rdf:datatype="http://www.w3.org/2001/XMLSchema#date">2020-01-01</date>
+  </rdf:Description>
+</rdf:RDF>
+`
+	if string(b) != expected {
+		t.Errorf("Expecting %s but got %s", expected, b)
+	}
+
+	g.Triples = append(g.Triples, Triple{s, Term{Kind: IRI, Value: "http://example.com/2020"}, Term{Kind: Literal, Value: "x"}})
+	if _, err := g.RDFXML(); err == nil {
+		t.Errorf("Expecting an error for a predicate without a valid element name")
+	}
+}
@@ -0,0 +1,321 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"strings"
+	"time"
+)
+
+// schemaPrefixes are the forms of the schema.org namespace seen in practice.
+var schemaPrefixes = []string{
+	"http://schema.org/",
+	"https://schema.org/",
+	"http://www.schema.org/",
+	"https://www.schema.org/",
+}
+
+// schemaParents maps schema.org types to their parent types. It covers the
+// parts of the type hierarchy that the converters in this package rely on.
+var schemaParents = map[string][]string{
+	// Organization
+	"Airline":                  {"Organization"},
+	"Consortium":               {"Organization"},
+	"Corporation":              {"Organization"},
+	"EducationalOrganization":  {"Organization"},
+	"CollegeOrUniversity":      {"EducationalOrganization"},
+	"ElementarySchool":         {"EducationalOrganization"},
+	"HighSchool":               {"EducationalOrganization"},
+	"MiddleSchool":             {"EducationalOrganization"},
+	"Preschool":                {"EducationalOrganization"},
+	"School":                   {"EducationalOrganization"},
+	"FundingScheme":            {"Organization"},
+	"GovernmentOrganization":   {"Organization"},
+	"LibrarySystem":            {"Organization"},
+	"LocalBusiness":            {"Organization", "Place"},
+	"MedicalOrganization":      {"Organization"},
+	"NGO":                      {"Organization"},
+	"NewsMediaOrganization":    {"Organization"},
+	"OnlineBusiness":           {"Organization"},
+	"OnlineStore":              {"OnlineBusiness"},
+	"PerformingGroup":          {"Organization"},
+	"MusicGroup":               {"PerformingGroup"},
+	"DanceGroup":               {"PerformingGroup"},
+	"TheaterGroup":             {"PerformingGroup"},
+	"PoliticalParty":           {"Organization"},
+	"Project":                  {"Organization"},
+	"ResearchOrganization":     {"Organization"},
+	"SearchRescueOrganization": {"Organization"},
+	"SportsOrganization":       {"Organization"},
+	"SportsTeam":               {"SportsOrganization"},
+	"WorkersUnion":             {"Organization"},
+
+	// LocalBusiness
+	"AutomotiveBusiness":          {"LocalBusiness"},
+	"EntertainmentBusiness":       {"LocalBusiness"},
+	"FinancialService":            {"LocalBusiness"},
+	"FoodEstablishment":           {"LocalBusiness"},
+	"Bakery":                      {"FoodEstablishment"},
+	"BarOrPub":                    {"FoodEstablishment"},
+	"CafeOrCoffeeShop":            {"FoodEstablishment"},
+	"FastFoodRestaurant":          {"FoodEstablishment"},
+	"Restaurant":                  {"FoodEstablishment"},
+	"HealthAndBeautyBusiness":     {"LocalBusiness"},
+	"HomeAndConstructionBusiness": {"LocalBusiness"},
+	"LodgingBusiness":             {"LocalBusiness"},
+	"Hotel":                       {"LodgingBusiness"},
+	"ProfessionalService":         {"LocalBusiness"},
+	"RealEstateAgent":             {"LocalBusiness"},
+	"Store":                       {"LocalBusiness"},
+	"TravelAgency":                {"LocalBusiness"},
+
+	// Event
+	"BusinessEvent":    {"Event"},
+	"ChildrensEvent":   {"Event"},
+	"ComedyEvent":      {"Event"},
+	"CourseInstance":   {"Event"},
+	"DanceEvent":       {"Event"},
+	"DeliveryEvent":    {"Event"},
+	"EducationEvent":   {"Event"},
+	"EventSeries":      {"Event"},
+	"ExhibitionEvent":  {"Event"},
+	"Festival":         {"Event"},
+	"FoodEvent":        {"Event"},
+	"Hackathon":        {"Event"},
+	"LiteraryEvent":    {"Event"},
+	"MusicEvent":       {"Event"},
+	"PublicationEvent": {"Event"},
+	"BroadcastEvent":   {"PublicationEvent"},
+	"OnDemandEvent":    {"PublicationEvent"},
+	"SaleEvent":        {"Event"},
+	"ScreeningEvent":   {"Event"},
+	"SocialEvent":      {"Event"},
+	"SportsEvent":      {"Event"},
+	"TheaterEvent":     {"Event"},
+	"VisualArtsEvent":  {"Event"},
+
+	// CreativeWork
+	"Article":                  {"CreativeWork"},
+	"AdvertiserContentArticle": {"Article"},
+	"NewsArticle":              {"Article"},
+	"AnalysisNewsArticle":      {"NewsArticle"},
+	"BackgroundNewsArticle":    {"NewsArticle"},
+	"OpinionNewsArticle":       {"NewsArticle"},
+	"ReportageNewsArticle":     {"NewsArticle"},
+	"ReviewNewsArticle":        {"NewsArticle"},
+	"Report":                   {"Article"},
+	"SatiricalArticle":         {"Article"},
+	"ScholarlyArticle":         {"Article"},
+	"MedicalScholarlyArticle":  {"ScholarlyArticle"},
+	"SocialMediaPosting":       {"Article"},
+	"BlogPosting":              {"SocialMediaPosting"},
+	"LiveBlogPosting":          {"BlogPosting"},
+	"DiscussionForumPosting":   {"SocialMediaPosting"},
+	"TechArticle":              {"Article"},
+	"APIReference":             {"TechArticle"},
+	"Book":                     {"CreativeWork"},
+	"Audiobook":                {"Book"},
+	"Chapter":                  {"CreativeWork"},
+	"CreativeWorkSeries":       {"CreativeWork"},
+	"Periodical":               {"CreativeWorkSeries"},
+	"PublicationIssue":         {"CreativeWork"},
+	"PublicationVolume":        {"CreativeWork"},
+	"ComicIssue":               {"PublicationIssue"},
+	"Thesis":                   {"CreativeWork"},
+}
+
+// schemaType returns the local name of t if it is a schema.org type and the
+// empty string otherwise.
+func schemaType(t string) string {
+	for _, prefix := range schemaPrefixes {
+		if strings.HasPrefix(t, prefix) {
+			return strings.TrimPrefix(t, prefix)
+		}
+	}
+	return ""
+}
+
+// schemaIsA reports whether the schema.org type name is ancestor or one of
+// its subtypes.
+func schemaIsA(name, ancestor string) bool {
+	if name == ancestor {
+		return true
+	}
+	for _, parent := range schemaParents[name] {
+		if schemaIsA(parent, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSchemaType reports whether the item has a schema.org type that is one of
+// names or a subtype of one of them.
+func isSchemaType(item *Item, names ...string) bool {
+	for _, t := range item.Types {
+		local := schemaType(t)
+		if local == "" {
+			continue
+		}
+		for _, name := range names {
+			if schemaIsA(local, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasType reports whether the item has the type t.
+func hasType(item *Item, t string) bool {
+	return hasString(item.Types, t)
+}
+
+// enumValue returns the local name of a schema.org enumeration member, such
+// as EventCancelled for http://schema.org/EventCancelled.
+func enumValue(s string) string {
+	s = strings.TrimSpace(s)
+	if local := schemaType(s); local != "" {
+		return local
+	}
+	return s
+}
+
+// now returns the current time, used for timestamps written by converters.
+var now = time.Now
+
+// dateKind classifies the values accepted by parseDateTime.
+type dateKind int
+
+const (
+	dateOnly     dateKind = iota + 1 // a calendar date without a time
+	floatingTime                     // a date and time without a time zone
+	zonedTime                        // a date and time with a time zone offset
+)
+
+var (
+	zonedLayouts = []string{
+		"2006-01-02T15:04:05.999999999Z07:00",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04:05.999999999Z0700",
+		"2006-01-02T15:04Z0700",
+	}
+	floatingLayouts = []string{
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02T15:04",
+	}
+)
+
+// parseDateTime parses a date or date and time in the ISO 8601 forms allowed
+// by the datetime attribute of the time element. A space may separate the
+// date and time.
+func parseDateTime(s string) (time.Time, dateKind, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, zonedTime, true
+		}
+	}
+	for _, layout := range floatingLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, floatingTime, true
+		}
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, dateOnly, true
+	}
+	return time.Time{}, 0, false
+}
+
+// stringValue returns the first string value of the first of the named
+// properties that has one.
+func stringValue(item *Item, names ...string) string {
+	for _, name := range names {
+		for _, v := range item.Properties[name] {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// stringValues returns all the string values of the named property.
+func stringValues(item *Item, name string) []string {
+	values := make([]string, 0)
+	for _, v := range item.Properties[name] {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// firstNonEmpty returns the first of values that is not empty or whitespace.
+func firstNonEmpty(values []string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// firstValue returns the first value of the named property or nil if it has
+// none.
+func firstValue(item *Item, name string) interface{} {
+	if values := item.Properties[name]; len(values) > 0 {
+		return values[0]
+	}
+	return nil
+}
+
+// itemValue returns the first item value of the first of the named properties
+// that has one.
+func itemValue(item *Item, names ...string) *Item {
+	for _, name := range names {
+		for _, v := range item.Properties[name] {
+			if child, ok := v.(*Item); ok {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// textValue returns the text of a property value: strings are returned as they
+// are and items are represented by their name.
+func textValue(v interface{}) string {
+	switch tv := v.(type) {
+	case string:
+		return tv
+	case *Item:
+		return stringValue(tv, "name")
+	}
+	return ""
+}
+
+// urlValue returns the URL of a property value: strings are assumed to be URLs
+// and items, such as ImageObject, are represented by their contentUrl or url.
+func urlValue(v interface{}) string {
+	switch tv := v.(type) {
+	case string:
+		return tv
+	case *Item:
+		return stringValue(tv, "contentUrl", "url")
+	}
+	return ""
+}
+
+// cleanText collapses runs of whitespace, such as those left by markup
+// indentation, into single spaces.
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
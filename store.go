@@ -0,0 +1,290 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Store is an in-memory index of the triples of many microdata sets that
+// answers basic graph pattern queries. Items with the same ID in different
+// sets are the same subject, so queries can join across documents. Blank
+// nodes are scoped to the set they came from.
+type Store struct {
+	triples     []Triple
+	seen        map[Triple]bool
+	bySubject   map[Term][]int
+	byPredicate map[Term][]int
+	byObject    map[Term][]int
+	sets        int
+}
+
+// NewStore creates an empty store.
+func NewStore() *Store {
+	return &Store{
+		seen:        make(map[Triple]bool),
+		bySubject:   make(map[Term][]int),
+		byPredicate: make(map[Term][]int),
+		byObject:    make(map[Term][]int),
+	}
+}
+
+// Add converts a microdata set to triples, as described by Microdata.Graph,
+// and adds them to the store. Triples already in the store are not repeated.
+func (s *Store) Add(m *Microdata) {
+	for _, t := range microdataTriples(m, fmt.Sprintf("s%db", s.sets)) {
+		s.addTriple(t)
+	}
+	s.sets++
+}
+
+// AddGraph adds the triples of a graph to the store. Like those of a set
+// given to Add, its blank nodes are scoped to the graph and are relabelled so
+// they are not confused with those of other graphs that use the same labels.
+func (s *Store) AddGraph(g *Graph) {
+	labels := make(map[string]Term)
+	relabel := func(t Term) Term {
+		if t.Kind != Blank {
+			return t
+		}
+		if scoped, exists := labels[t.Value]; exists {
+			return scoped
+		}
+		scoped := Term{Kind: Blank, Value: fmt.Sprintf("s%db%d", s.sets, len(labels))}
+		labels[t.Value] = scoped
+		return scoped
+	}
+
+	for _, t := range g.Triples {
+		s.addTriple(Triple{relabel(t.Subject), relabel(t.Predicate), relabel(t.Object)})
+	}
+	s.sets++
+}
+
+func (s *Store) addTriple(t Triple) {
+	if s.seen[t] {
+		return
+	}
+	s.seen[t] = true
+	idx := len(s.triples)
+	s.triples = append(s.triples, t)
+	s.bySubject[t.Subject] = append(s.bySubject[t.Subject], idx)
+	s.byPredicate[t.Predicate] = append(s.byPredicate[t.Predicate], idx)
+	s.byObject[t.Object] = append(s.byObject[t.Object], idx)
+}
+
+// Len returns the number of triples in the store.
+func (s *Store) Len() int {
+	return len(s.triples)
+}
+
+// PatternTerm is a term of a Pattern: either a Term, which must match
+// exactly, or a Var. A nil PatternTerm matches any term without binding it.
+type PatternTerm interface {
+	patternTerm()
+}
+
+func (Term) patternTerm() {}
+
+// Var is a query variable with the given name, for use in a Pattern.
+type Var string
+
+func (Var) patternTerm() {}
+
+// Pattern is a triple pattern whose terms may be variables.
+type Pattern struct {
+	Subject, Predicate, Object PatternTerm
+}
+
+// FilterOp is a comparison made by a Filter.
+type FilterOp int
+
+// Comparisons made by filters. FilterLess, FilterLessOrEqual, FilterGreater
+// and FilterGreaterOrEqual compare numerically when both values are numbers
+// and as strings otherwise.
+const (
+	FilterEqual FilterOp = iota
+	FilterNotEqual
+	FilterLess
+	FilterLessOrEqual
+	FilterGreater
+	FilterGreaterOrEqual
+	FilterContains
+)
+
+// Filter restricts the solutions of a query to those where the value bound to
+// Variable compares with Value as given by Op.
+type Filter struct {
+	Variable string
+	Op       FilterOp
+	Value    string
+}
+
+// Query is a basic graph pattern: a solution binds the variables of all the
+// patterns so that each matches a triple in the store and all the filters are
+// satisfied. Patterns sharing a variable are joined on it.
+type Query struct {
+	Patterns []Pattern
+	Filters  []Filter
+}
+
+// Binding maps variable names to the terms bound to them in a solution.
+type Binding map[string]Term
+
+// Query returns the solutions of a query in the order they are found, which
+// follows the order of the patterns and of the triples added to the store.
+func (s *Store) Query(q Query) []Binding {
+	solutions := []Binding{{}}
+	for _, p := range q.Patterns {
+		next := make([]Binding, 0)
+		for _, b := range solutions {
+			for _, idx := range s.candidates(p, b) {
+				if nb, ok := matchPattern(p, s.triples[idx], b); ok && passesFilters(q.Filters, nb) {
+					next = append(next, nb)
+				}
+			}
+		}
+		solutions = next
+		if len(solutions) == 0 {
+			break
+		}
+	}
+
+	// Filters on variables that no pattern binds can never be satisfied.
+	for _, f := range q.Filters {
+		for _, b := range solutions {
+			if _, bound := b[f.Variable]; !bound {
+				return []Binding{}
+			}
+		}
+	}
+	return solutions
+}
+
+// candidates returns the indexes of the triples that may match a pattern
+// given the variables already bound, using the smallest index available.
+func (s *Store) candidates(p Pattern, b Binding) []int {
+	var best []int
+	found := false
+	for _, c := range []struct {
+		t     PatternTerm
+		index map[Term][]int
+	}{
+		{p.Subject, s.bySubject},
+		{p.Predicate, s.byPredicate},
+		{p.Object, s.byObject},
+	} {
+		t, bound := resolveTerm(c.t, b)
+		if !bound {
+			continue
+		}
+		if list := c.index[t]; !found || len(list) < len(best) {
+			best, found = list, true
+		}
+	}
+	if !found {
+		all := make([]int, len(s.triples))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	return best
+}
+
+// resolveTerm returns the term bound to a variable, or the term itself if it
+// is not a variable. It reports false for unbound variables and nil terms.
+func resolveTerm(t PatternTerm, b Binding) (Term, bool) {
+	switch tv := t.(type) {
+	case Term:
+		return tv, true
+	case Var:
+		bound, exists := b[string(tv)]
+		return bound, exists
+	}
+	return Term{}, false
+}
+
+// matchPattern matches a triple against a pattern, returning the binding
+// extended with the pattern's newly bound variables.
+func matchPattern(p Pattern, t Triple, b Binding) (Binding, bool) {
+	nb, copied := b, false
+	for _, pair := range []struct {
+		pt     PatternTerm
+		actual Term
+	}{{p.Subject, t.Subject}, {p.Predicate, t.Predicate}, {p.Object, t.Object}} {
+		pt, actual := pair.pt, pair.actual
+		v, isVar := pt.(Var)
+		if !isVar {
+			if term, ok := pt.(Term); ok && term != actual {
+				return nil, false
+			}
+			continue
+		}
+		if bound, exists := nb[string(v)]; exists {
+			if bound != actual {
+				return nil, false
+			}
+			continue
+		}
+		if !copied {
+			nb, copied = make(Binding, len(b)+3), true
+			for k, v := range b {
+				nb[k] = v
+			}
+		}
+		nb[string(v)] = actual
+	}
+	return nb, true
+}
+
+func passesFilters(filters []Filter, b Binding) bool {
+	for _, f := range filters {
+		if t, bound := b[f.Variable]; bound && !f.matches(t.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) matches(value string) bool {
+	switch f.Op {
+	case FilterEqual:
+		return value == f.Value
+	case FilterNotEqual:
+		return value != f.Value
+	case FilterContains:
+		return strings.Contains(value, f.Value)
+	}
+
+	cmp := strings.Compare(value, f.Value)
+	a, errA := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(f.Value), 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch f.Op {
+	case FilterLess:
+		return cmp < 0
+	case FilterLessOrEqual:
+		return cmp <= 0
+	case FilterGreater:
+		return cmp > 0
+	case FilterGreaterOrEqual:
+		return cmp >= 0
+	}
+	return false
+}
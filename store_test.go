@@ -0,0 +1,145 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"testing"
+)
+
+func offerStore(t *testing.T) *Store {
+	s := NewStore()
+	s.Add(ParseData(`<div itemscope itemtype="http://schema.org/Offer">
+	  <span itemprop="price">15.00</span><span itemprop="priceCurrency">EUR</span>
+	  <link itemprop="seller" href="http://example.com/acme">
+	</div>
+	<div itemscope itemtype="http://schema.org/Offer">
+	  <span itemprop="price">25.00</span><span itemprop="priceCurrency">EUR</span>
+	  <link itemprop="seller" href="http://example.com/acme">
+	</div>`, t))
+	s.Add(ParseData(`<div itemscope itemtype="http://schema.org/Offer">
+	  <span itemprop="price">9.5</span><span itemprop="priceCurrency">EUR</span>
+	  <div itemprop="seller" itemscope itemtype="http://schema.org/Organization" itemid="http://example.com/other">
+	    <span itemprop="name">Other</span>
+	  </div>
+	</div>
+	<div itemscope itemtype="http://schema.org/Offer">
+	  <span itemprop="price">5</span><span itemprop="priceCurrency">USD</span>
+	  <link itemprop="seller" href="http://example.com/acme">
+	</div>`, t))
+	s.Add(ParseData(`<div itemscope itemtype="http://schema.org/Organization" itemid="http://example.com/acme">
+	  <span itemprop="name">Acme</span>
+	</div>`, t))
+	return s
+}
+
+func iri(v string) Term {
+	return Term{Kind: IRI, Value: v}
+}
+
+func TestStoreQuery(t *testing.T) {
+	s := offerStore(t)
+
+	// all Offers under 20 EUR whose seller is Acme
+	solutions := s.Query(Query{
+		Patterns: []Pattern{
+			{Var("offer"), iri(rdfType), iri("http://schema.org/Offer")},
+			{Var("offer"), iri("http://schema.org/seller"), iri("http://example.com/acme")},
+			{Var("offer"), iri("http://schema.org/priceCurrency"), Term{Kind: Literal, Value: "EUR"}},
+			{Var("offer"), iri("http://schema.org/price"), Var("price")},
+		},
+		Filters: []Filter{{Variable: "price", Op: FilterLess, Value: "20"}},
+	})
+
+	if len(solutions) != 1 {
+		t.Fatalf("Expecting 1 solution but got %d: %v", len(solutions), solutions)
+	}
+	if solutions[0]["price"].Value != "15.00" {
+		t.Errorf("Expecting price 15.00 but got %v", solutions[0]["price"])
+	}
+	if solutions[0]["offer"].Kind != Blank || solutions[0]["offer"].Value != "s0b0" {
+		t.Errorf("Expecting offer to be blank node s0b0 but got %v", solutions[0]["offer"])
+	}
+}
+
+func TestStoreJoinAcrossDocuments(t *testing.T) {
+	s := offerStore(t)
+
+	// seller names come from a different document for Acme's offers
+	solutions := s.Query(Query{
+		Patterns: []Pattern{
+			{Var("offer"), iri("http://schema.org/seller"), Var("seller")},
+			{Var("seller"), iri("http://schema.org/name"), Var("name")},
+			{Var("offer"), iri("http://schema.org/price"), Var("price")},
+		},
+		Filters: []Filter{{Variable: "price", Op: FilterGreaterOrEqual, Value: "9.5"}},
+	})
+
+	expected := []string{"Acme 15.00", "Acme 25.00", "Other 9.5"}
+	if len(solutions) != len(expected) {
+		t.Fatalf("Expecting %d solutions but got %d: %v", len(expected), len(solutions), solutions)
+	}
+	for i, b := range solutions {
+		if got := b["name"].Value + " " + b["price"].Value; got != expected[i] {
+			t.Errorf("Expecting solution %d to be %s but got %s", i, expected[i], got)
+		}
+	}
+}
+
+func TestStoreFilters(t *testing.T) {
+	s := offerStore(t)
+	pattern := []Pattern{{Var("s"), iri("http://schema.org/name"), Var("name")}}
+
+	testCases := []struct {
+		filter   Filter
+		expected int
+	}{
+		{Filter{Variable: "name", Op: FilterEqual, Value: "Acme"}, 1},
+		{Filter{Variable: "name", Op: FilterNotEqual, Value: "Acme"}, 1},
+		{Filter{Variable: "name", Op: FilterContains, Value: "e"}, 2},
+		{Filter{Variable: "name", Op: FilterGreater, Value: "B"}, 1},
+		{Filter{Variable: "missing", Op: FilterEqual, Value: "x"}, 0},
+	}
+
+	for _, tc := range testCases {
+		if solutions := s.Query(Query{Patterns: pattern, Filters: []Filter{tc.filter}}); len(solutions) != tc.expected {
+			t.Errorf("Expecting %d solutions for %v but got %d", tc.expected, tc.filter, len(solutions))
+		}
+	}
+}
+
+func TestStoreDeduplicates(t *testing.T) {
+	s := NewStore()
+	html := `<div itemscope itemtype="http://schema.org/Thing" itemid="http://example.com/a"><span itemprop="name">A</span></div>`
+	s.Add(ParseData(html, t))
+	s.Add(ParseData(html, t))
+	if s.Len() != 2 {
+		t.Errorf("Expecting 2 triples but got %d", s.Len())
+	}
+}
+
+func TestStoreAddGraphScopesBlankNodes(t *testing.T) {
+	a := ParseData(`<div itemscope itemtype="http://schema.org/Person"><span itemprop="name">A</span></div>`, t)
+	b := ParseData(`<div itemscope itemtype="http://schema.org/Person"><span itemprop="name">B</span></div>`, t)
+
+	s := NewStore()
+	s.AddGraph(a.Graph())
+	s.AddGraph(b.Graph())
+
+	solutions := s.Query(Query{Patterns: []Pattern{{Var("p"), iri("http://schema.org/name"), Var("name")}}})
+	if len(solutions) != 2 || solutions[0]["p"] == solutions[1]["p"] {
+		t.Errorf("Expecting two distinct people but got %v", solutions)
+	}
+}
+
+func TestStoreWildcard(t *testing.T) {
+	s := offerStore(t)
+
+	// nil terms match anything without binding a variable
+	solutions := s.Query(Query{Patterns: []Pattern{{Var("seller"), iri("http://schema.org/name"), nil}}})
+	if len(solutions) != 2 || len(solutions[0]) != 1 {
+		t.Errorf("Expecting 2 solutions binding only seller but got %v", solutions)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// MultiValueMode controls how properties with several values are written to
+// a table.
+type MultiValueMode int
+
+const (
+	// JoinValues writes all the values of a column into one cell, separated
+	// by TableOptions.Separator.
+	JoinValues MultiValueMode = iota
+
+	// ExplodeValues writes each value of the property named by
+	// TableOptions.Explode on its own row, repeating the values of the other
+	// columns, which are joined as they are by JoinValues. Each item has as
+	// many rows as that property has values, or one if it has none.
+	ExplodeValues
+
+	// FirstValue writes only the first value of each property.
+	FirstValue
+)
+
+// Columns holding the ID and types of each item.
+const (
+	IDColumn   = "@id"
+	TypeColumn = "@type"
+)
+
+// TableOptions configures the flattening of items into a table.
+type TableOptions struct {
+	// Type selects the items to write. A schema.org type may be given by its
+	// local name, such as Product, to match any form of the schema.org
+	// namespace. When empty the top-level items of the set are written.
+	Type string
+
+	// MultiValue controls how properties with several values are written.
+	MultiValue MultiValueMode
+
+	// Explode is the dotted path of the property whose values are written on
+	// separate rows by ExplodeValues, such as offers or offers.seller.
+	Explode string
+
+	// Separator separates joined values. It defaults to "|".
+	Separator string
+
+	// Comma is the field delimiter used by CSV. It defaults to ',' and may be
+	// set to '\t' to write TSV.
+	Comma rune
+}
+
+// Table flattens items into rows. Nested item properties become columns with
+// dotted names such as offers.price or brand.name. The columns appear in the
+// order they are first found across all items, with the IDColumn and
+// TypeColumn first when any item has an ID or types.
+func (m *Microdata) Table(opts TableOptions) (header []string, rows [][]string) {
+	items := m.tableItems(opts.Type)
+	separator := opts.Separator
+	if separator == "" {
+		separator = "|"
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	addColumn := func(col string) {
+		if !seen[col] {
+			seen[col] = true
+			columns = append(columns, col)
+		}
+	}
+
+	hasID, hasType := false, false
+	for _, item := range items {
+		hasID = hasID || item.ID != ""
+		hasType = hasType || len(item.Types) > 0
+	}
+	if hasID {
+		addColumn(IDColumn)
+	}
+	if hasType {
+		addColumn(TypeColumn)
+	}
+
+	explode := ""
+	if opts.MultiValue == ExplodeValues {
+		explode = opts.Explode
+	}
+	joinCells := func(cells map[string][]string) map[string]string {
+		record := make(map[string]string, len(cells))
+		for col, values := range cells {
+			record[col] = strings.Join(values, separator)
+		}
+		return record
+	}
+
+	records := make([]map[string]string, 0)
+	for _, item := range items {
+		cells := make(map[string][]string)
+		collectCells(item, "", opts.MultiValue == FirstValue, explode, cells, map[*Item]bool{}, addColumn)
+		itemRecords := []map[string]string{joinCells(cells)}
+
+		if values := pathValues(item, explode); len(values) > 0 {
+			itemRecords = make([]map[string]string, 0, len(values))
+			for _, v := range values {
+				exploded := make(map[string][]string, len(cells)+1)
+				for col, values := range cells {
+					exploded[col] = values
+				}
+				switch tv := v.(type) {
+				case string:
+					addColumn(explode)
+					exploded[explode] = []string{cleanText(tv)}
+				case *Item:
+					collectCells(tv, explode+".", false, "", exploded, map[*Item]bool{item: true}, addColumn)
+				}
+				itemRecords = append(itemRecords, joinCells(exploded))
+			}
+		}
+
+		for _, record := range itemRecords {
+			if item.ID != "" {
+				record[IDColumn] = item.ID
+			}
+			if len(item.Types) > 0 {
+				record[TypeColumn] = strings.Join(item.Types, " ")
+			}
+			records = append(records, record)
+		}
+	}
+
+	rows = make([][]string, len(records))
+	for i, record := range records {
+		row := make([]string, len(columns))
+		for j, col := range columns {
+			row[j] = record[col]
+		}
+		rows[i] = row
+	}
+	return columns, rows
+}
+
+// CSV flattens items into a table as described by Table and writes it as
+// CSV, or TSV when opts.Comma is a tab, with a header row of column names.
+func (m *Microdata) CSV(opts TableOptions) ([]byte, error) {
+	header, rows := m.Table(opts)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if opts.Comma != 0 {
+		w.Comma = opts.Comma
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tableItems returns the items with type t, or the top-level items if t is
+// empty.
+func (m *Microdata) tableItems(t string) []*Item {
+	if t == "" {
+		return m.Items
+	}
+
+	items := make([]*Item, 0)
+	m.Walk(func(v Visit) error {
+		item, ok := v.Value.(*Item)
+		if ok && !v.Shared && (hasType(item, t) || hasString(schemaTypes(item), t)) {
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items
+}
+
+// schemaTypes returns the local names of the item's schema.org types.
+func schemaTypes(item *Item) []string {
+	names := make([]string, 0, len(item.Types))
+	for _, t := range item.Types {
+		if local := schemaType(t); local != "" {
+			names = append(names, local)
+		}
+	}
+	return names
+}
+
+// collectCells gathers the values of each column of an item, descending into
+// nested items, apart from those of the skip column. Items that are their own
+// ancestors are not descended into.
+func collectCells(item *Item, prefix string, firstOnly bool, skip string, cells map[string][]string, ancestors map[*Item]bool, addColumn func(string)) {
+	ancestors[item] = true
+	defer delete(ancestors, item)
+
+	for _, name := range item.PropertyNames() {
+		col := prefix + name
+		if col == skip {
+			// keep the column in document order; its values are added later
+			if len(stringValues(item, name)) > 0 {
+				addColumn(col)
+			}
+			continue
+		}
+		for _, v := range item.Properties[name] {
+			switch tv := v.(type) {
+			case string:
+				addColumn(col)
+				cells[col] = append(cells[col], cleanText(tv))
+			case *Item:
+				if !ancestors[tv] {
+					collectCells(tv, col+".", firstOnly, skip, cells, ancestors, addColumn)
+				}
+			}
+			if firstOnly {
+				break
+			}
+		}
+	}
+}
+
+// pathValues returns the values of the property at a dotted path such as
+// offers.seller, gathered from every item along the path.
+func pathValues(item *Item, path string) valueList {
+	if path == "" {
+		return nil
+	}
+	names := strings.Split(path, ".")
+	items := []*Item{item}
+	for _, name := range names[:len(names)-1] {
+		next := make([]*Item, 0)
+		for _, it := range items {
+			for _, v := range it.Properties[name] {
+				if child, ok := v.(*Item); ok {
+					next = append(next, child)
+				}
+			}
+		}
+		items = next
+	}
+
+	values := make(valueList, 0)
+	for _, it := range items {
+		values = append(values, it.Properties[names[len(names)-1]]...)
+	}
+	return values
+}
@@ -0,0 +1,126 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const productsHTML = `<div itemscope itemtype="http://schema.org/Product">
+  <span itemprop="name">Widget</span>
+  <div itemprop="brand" itemscope itemtype="http://schema.org/Brand"><span itemprop="name">ACME</span></div>
+  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+    <span itemprop="price">10.00</span>
+  </div>
+  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+    <span itemprop="price">12.00</span>
+  </div>
+</div>
+<div itemscope itemtype="http://schema.org/Product">
+  <span itemprop="name">Gadget</span>
+  <span itemprop="color">Red</span>
+</div>`
+
+func TestTableJoin(t *testing.T) {
+	header, rows := ParseData(productsHTML, t).Table(TableOptions{Type: "Product"})
+
+	expectedHeader := []string{"@type", "name", "brand.name", "offers.price", "color"}
+	if !reflect.DeepEqual(header, expectedHeader) {
+		t.Errorf("Expecting header %v but got %v", expectedHeader, header)
+	}
+
+	expectedRows := [][]string{
+		{"http://schema.org/Product", "Widget", "ACME", "10.00|12.00", ""},
+		{"http://schema.org/Product", "Gadget", "", "", "Red"},
+	}
+	if !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("Expecting rows %v but got %v", expectedRows, rows)
+	}
+}
+
+func TestTableExplodeAndFirst(t *testing.T) {
+	data := ParseData(productsHTML, t)
+
+	_, rows := data.Table(TableOptions{MultiValue: ExplodeValues, Explode: "offers"})
+	expected := [][]string{
+		{"http://schema.org/Product", "Widget", "ACME", "10.00", ""},
+		{"http://schema.org/Product", "Widget", "ACME", "12.00", ""},
+		{"http://schema.org/Product", "Gadget", "", "", "Red"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expecting exploded rows %v but got %v", expected, rows)
+	}
+
+	_, rows = data.Table(TableOptions{MultiValue: FirstValue})
+	expected = [][]string{
+		{"http://schema.org/Product", "Widget", "ACME", "10.00", ""},
+		{"http://schema.org/Product", "Gadget", "", "", "Red"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expecting first value rows %v but got %v", expected, rows)
+	}
+}
+
+func TestTableExplodeSize(t *testing.T) {
+	item := NewItem()
+	for _, name := range []string{"image", "review", "color", "size", "material", "keywords"} {
+		for i := 0; i < 10; i++ {
+			item.AddString(name, name+strconv.Itoa(i))
+		}
+	}
+	data := NewMicrodata()
+	data.AddItem(item)
+
+	header, rows := data.Table(TableOptions{MultiValue: ExplodeValues, Explode: "image"})
+	if len(rows) != 10 {
+		t.Fatalf("Expecting a row for each image but got %d rows", len(rows))
+	}
+	if !reflect.DeepEqual(header, []string{"image", "review", "color", "size", "material", "keywords"}) {
+		t.Errorf("Expecting columns in document order but got %v", header)
+	}
+	if rows[3][0] != "image3" || rows[3][1] != "review0|review1|review2|review3|review4|review5|review6|review7|review8|review9" {
+		t.Errorf("Expecting other values to be joined but got %v", rows[3])
+	}
+
+	if _, rows := data.Table(TableOptions{MultiValue: ExplodeValues}); len(rows) != 1 {
+		t.Errorf("Expecting one row without an Explode property but got %d", len(rows))
+	}
+}
+
+func TestTableExplodeNestedPath(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Product">
+	  <span itemprop="name">Widget</span>
+	  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+	    <span itemprop="price">10.00</span>
+	    <div itemprop="seller" itemscope><span itemprop="name">A</span></div>
+	    <div itemprop="seller" itemscope><span itemprop="name">B</span></div>
+	  </div>
+	</div>`
+
+	header, rows := ParseData(html, t).Table(TableOptions{MultiValue: ExplodeValues, Explode: "offers.seller"})
+	expectedHeader := []string{"@type", "name", "offers.price", "offers.seller.name"}
+	expectedRows := [][]string{
+		{"http://schema.org/Product", "Widget", "10.00", "A"},
+		{"http://schema.org/Product", "Widget", "10.00", "B"},
+	}
+	if !reflect.DeepEqual(header, expectedHeader) || !reflect.DeepEqual(rows, expectedRows) {
+		t.Errorf("Expecting %v %v but got %v %v", expectedHeader, expectedRows, header, rows)
+	}
+}
+
+func TestCSVNestedType(t *testing.T) {
+	b, err := ParseData(productsHTML, t).CSV(TableOptions{Type: "http://schema.org/Offer", Comma: '\t'})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := "@type\tprice\nhttp://schema.org/Offer\t10.00\nhttp://schema.org/Offer\t12.00\n"
+	if string(b) != expected {
+		t.Errorf("Expecting %q but got %q", expected, b)
+	}
+}
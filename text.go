@@ -0,0 +1,114 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// textValueLimit is the number of characters of a string value shown by the
+// text renderers before it is truncated.
+const textValueLimit = 80
+
+// Tree renders the items as an indented tree for reading. Each item is shown
+// by its types and ID, followed by its properties one per line as
+// "name: value", with nested items indented beneath the property holding
+// them. Whitespace in values is collapsed and long values are truncated. An
+// item nested within itself is marked as a cycle and not expanded again.
+func (m *Microdata) Tree() string {
+	var b strings.Builder
+	m.Walk(func(v Visit) error {
+		depth := len(v.Path) - 1
+		indent := strings.Repeat("  ", depth)
+
+		item, isItem := v.Value.(*Item)
+		switch {
+		case v.Parent == nil:
+			fmt.Fprintf(&b, "%s\n", itemHeading(item))
+		case isItem && v.Cycle:
+			fmt.Fprintf(&b, "%s%s: %s (cycle)\n", indent, v.Property, itemHeading(item))
+		case isItem:
+			fmt.Fprintf(&b, "%s%s: %s\n", indent, v.Property, itemHeading(item))
+		default:
+			fmt.Fprintf(&b, "%s%s: %s\n", indent, v.Property, truncate(cleanText(v.Value.(string)), textValueLimit))
+		}
+		return nil
+	})
+	return b.String()
+}
+
+// Markdown renders the items as a Markdown report with a section for each
+// top-level item, headed by the local name of its first type. Each section
+// lists the item's types and ID followed by a table of its string values, with
+// those of nested items named by dotted paths such as offers.price. Long
+// values are truncated.
+func (m *Microdata) Markdown() string {
+	var b strings.Builder
+	for idx, item := range m.Items {
+		if idx > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %d. %s\n\n", idx+1, markdownEscape(typeLabel(item)))
+		if len(item.Types) > 0 {
+			fmt.Fprintf(&b, "- **Type:** %s\n", markdownEscape(strings.Join(item.Types, ", ")))
+		}
+		if item.ID != "" {
+			fmt.Fprintf(&b, "- **ID:** %s\n", markdownEscape(item.ID))
+		}
+		if len(item.Types) > 0 || item.ID != "" {
+			b.WriteString("\n")
+		}
+
+		b.WriteString("| Property | Value |\n")
+		b.WriteString("| --- | --- |\n")
+		item.Walk(func(v Visit) error {
+			switch tv := v.Value.(type) {
+			case string:
+				fmt.Fprintf(&b, "| %s | %s |\n", markdownEscape(v.Path.Properties()), markdownEscape(truncate(cleanText(tv), textValueLimit)))
+			case *Item:
+				if v.Cycle {
+					fmt.Fprintf(&b, "| %s | %s (cycle) |\n", markdownEscape(v.Path.Properties()), markdownEscape(itemHeading(tv)))
+				}
+			}
+			return nil
+		})
+	}
+	return b.String()
+}
+
+// itemHeading describes an item by its types and ID.
+func itemHeading(item *Item) string {
+	heading := "item"
+	if len(item.Types) > 0 {
+		heading = strings.Join(item.Types, " ")
+	}
+	if item.ID != "" {
+		heading += " <" + item.ID + ">"
+	}
+	return heading
+}
+
+// typeLabel returns the local name of the item's first type, such as Product
+// for http://schema.org/Product, or "Item" if it has no type.
+func typeLabel(item *Item) string {
+	if len(item.Types) == 0 {
+		return "Item"
+	}
+	t := strings.TrimRight(item.Types[0], "/#")
+	if i := strings.LastIndexAny(t, "/#"); i >= 0 && i < len(t)-1 {
+		return t[i+1:]
+	}
+	return t
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, `*`, `\*`, `_`, `\_`, "`", "\\`", `<`, `&lt;`, `>`, `&gt;`)
+
+// markdownEscape escapes the characters that Markdown would treat as
+// formatting or that would break a table cell.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,91 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"strings"
+	"testing"
+)
+
+const textHTML = `<div itemscope itemtype="http://schema.org/Product" itemid="http://example.com/widget">
+  <span itemprop="name">Widget
+    Deluxe</span>
+  <span itemprop="description">` + "A very long description that goes on and on well beyond the limit of eighty characters" + `</span>
+  <div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+    <span itemprop="price">12.50</span>
+    <span itemprop="priceCurrency">USD</span>
+  </div>
+</div>
+<div itemscope><span itemprop="note">a|b</span></div>`
+
+func TestTree(t *testing.T) {
+	data := ParseData(textHTML, t)
+	data.Items[1].AddItem("self", data.Items[1])
+
+	expected := `http://schema.org/Product <http://example.com/widget>
+  name: Widget Deluxe
+  description: A very long description that goes on and on well beyond the limit of eighty cha…
+  offers: http://schema.org/Offer
+    price: 12.50
+    priceCurrency: USD
+item
+  note: a|b
+  self: item (cycle)
+`
+	if got := data.Tree(); got != expected {
+		t.Errorf("Expecting %s but got %s", expected, got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	data := ParseData(textHTML, t)
+
+	expected := `## 1. Product
+
+- **Type:** http://schema.org/Product
+- **ID:** http://example.com/widget
+
+| Property | Value |
+| --- | --- |
+| name | Widget Deluxe |
+| description | A very long description that goes on and on well beyond the limit of eighty cha… |
+| offers.price | 12.50 |
+| offers.priceCurrency | USD |
+
+## 2. Item
+
+| Property | Value |
+| --- | --- |
+| note | a\|b |
+`
+	if got := data.Markdown(); got != expected {
+		t.Errorf("Expecting %s but got %s", expected, got)
+	}
+}
+
+func TestTypeLabel(t *testing.T) {
+	testCases := []struct {
+		types    []string
+		expected string
+	}{
+		{[]string{"http://schema.org/Person"}, "Person"},
+		{[]string{"http://xmlns.com/foaf/0.1/Person"}, "Person"},
+		{[]string{"http://ogp.me/ns#article"}, "article"},
+		{[]string{"http://microformats.org/profile/h-card"}, "h-card"},
+		{[]string{"Thing/"}, "Thing"},
+		{nil, "Item"},
+	}
+
+	for _, tc := range testCases {
+		item := NewItem()
+		for _, typ := range tc.types {
+			item.AddType(typ)
+		}
+		if got := typeLabel(item); got != tc.expected {
+			t.Errorf("Expecting %s for %s but got %s", tc.expected, strings.Join(tc.types, " "), got)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// HCardType is the item type of the vCard vocabulary defined by earlier
+// versions of the HTML microdata specification.
+const HCardType = "http://microformats.org/profile/hcard"
+
+// VCard converts the Person and Organization items in the set, including
+// nested ones such as the employees of an organization, into a sequence of
+// vCard 4.0 records as defined by RFC 6350. Items typed with the hCard
+// vocabulary are converted too. Other items are ignored.
+func (m *Microdata) VCard() ([]byte, error) {
+	var buf bytes.Buffer
+	err := m.Walk(func(v Visit) error {
+		item, ok := v.Value.(*Item)
+		if !ok || v.Shared {
+			return nil
+		}
+		if isVCardItem(item) {
+			writeVCard(&buf, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// VCard converts the item into a vCard 4.0 record. The item must be a
+// schema.org Person or Organization or be typed with the hCard vocabulary.
+func (i *Item) VCard() ([]byte, error) {
+	if !isVCardItem(i) {
+		return nil, fmt.Errorf("microdata: item with types %v cannot be converted to a vCard", i.Types)
+	}
+	var buf bytes.Buffer
+	writeVCard(&buf, i)
+	return buf.Bytes(), nil
+}
+
+func isVCardItem(item *Item) bool {
+	return hasType(item, HCardType) || isSchemaType(item, "Person", "Organization")
+}
+
+func writeVCard(buf *bytes.Buffer, item *Item) {
+	writeContentLine(buf, "BEGIN", "VCARD")
+	writeContentLine(buf, "VERSION", "4.0")
+	if hasType(item, HCardType) {
+		writeHCardProperties(buf, item)
+	} else {
+		writeSchemaVCardProperties(buf, item)
+	}
+	writeContentLine(buf, "END", "VCARD")
+}
+
+func writeSchemaVCardProperties(buf *bytes.Buffer, item *Item) {
+	person := isSchemaType(item, "Person")
+
+	name := cleanText(stringValue(item, "name"))
+	if name == "" && person {
+		name = cleanText(strings.Join([]string{stringValue(item, "honorificPrefix"), stringValue(item, "givenName"), stringValue(item, "additionalName"), stringValue(item, "familyName"), stringValue(item, "honorificSuffix")}, " "))
+	}
+
+	if person {
+		writeContentLine(buf, "KIND", "individual")
+	} else {
+		writeContentLine(buf, "KIND", "org")
+	}
+	writeContentLine(buf, "FN", escapeText(name))
+
+	if person {
+		writeContentLine(buf, "N", structuredText(
+			stringValue(item, "familyName"),
+			stringValue(item, "givenName"),
+			stringValue(item, "additionalName"),
+			stringValue(item, "honorificPrefix"),
+			stringValue(item, "honorificSuffix"),
+		))
+		for _, prop := range []string{"worksFor", "affiliation", "memberOf"} {
+			for _, v := range item.Properties[prop] {
+				if org := textValue(v); org != "" {
+					writeContentLine(buf, "ORG", structuredText(org))
+				}
+			}
+		}
+		writeTextLines(buf, "TITLE", stringValues(item, "jobTitle"))
+		if bday := stringValue(item, "birthDate"); bday != "" {
+			writeContentLine(buf, "BDAY", vCardDate(bday))
+		}
+		writeURILines(buf, "PHOTO", item.Properties["image"])
+	} else {
+		writeContentLine(buf, "ORG", structuredText(name))
+		writeURILines(buf, "LOGO", item.Properties["logo"])
+		writeURILines(buf, "PHOTO", item.Properties["image"])
+	}
+
+	for _, email := range stringValues(item, "email") {
+		writeContentLine(buf, "EMAIL", escapeText(strings.TrimPrefix(cleanText(email), "mailto:")))
+	}
+	for _, tel := range stringValues(item, "telephone") {
+		writeTelephone(buf, nil, tel)
+	}
+	for _, fax := range stringValues(item, "faxNumber") {
+		writeTelephone(buf, []string{"fax"}, fax)
+	}
+
+	for _, v := range item.Properties["address"] {
+		switch address := v.(type) {
+		case string:
+			writeContentLine(buf, "ADR", structuredText("", "", address, "", "", "", ""))
+		case *Item:
+			writeContentLine(buf, "ADR", structuredText(
+				stringValue(address, "postOfficeBoxNumber"),
+				"",
+				stringValue(address, "streetAddress"),
+				stringValue(address, "addressLocality"),
+				stringValue(address, "addressRegion"),
+				stringValue(address, "postalCode"),
+				textValue(firstValue(address, "addressCountry")),
+			))
+		}
+	}
+
+	writeURILines(buf, "URL", item.Properties["url"])
+	writeTextLines(buf, "NOTE", stringValues(item, "description"))
+	if item.ID != "" {
+		writeContentLine(buf, "UID", item.ID)
+	}
+}
+
+func writeHCardProperties(buf *bytes.Buffer, item *Item) {
+	writeContentLine(buf, "FN", escapeText(cleanText(stringValue(item, "fn"))))
+
+	if n := itemValue(item, "n"); n != nil {
+		writeContentLine(buf, "N", structuredText(
+			stringValue(n, "family-name"),
+			stringValue(n, "given-name"),
+			stringValue(n, "additional-name"),
+			stringValue(n, "honorific-prefix"),
+			stringValue(n, "honorific-suffix"),
+		))
+	}
+
+	writeTextLines(buf, "KIND", stringValues(item, "kind"))
+	writeTextLines(buf, "NICKNAME", stringValues(item, "nickname"))
+	for _, bday := range stringValues(item, "bday") {
+		writeContentLine(buf, "BDAY", vCardDate(bday))
+	}
+	writeURILines(buf, "PHOTO", item.Properties["photo"])
+	writeURILines(buf, "LOGO", item.Properties["logo"])
+
+	for _, v := range item.Properties["adr"] {
+		if adr, ok := v.(*Item); ok {
+			writeContentLine(buf, "ADR"+typeParam(stringValues(adr, "type")), structuredText(
+				stringValue(adr, "post-office-box"),
+				stringValue(adr, "extended-address"),
+				stringValue(adr, "street-address"),
+				stringValue(adr, "locality"),
+				stringValue(adr, "region"),
+				stringValue(adr, "postal-code"),
+				stringValue(adr, "country-name"),
+			))
+		}
+	}
+
+	for _, v := range item.Properties["tel"] {
+		switch tel := v.(type) {
+		case string:
+			writeTelephone(buf, nil, tel)
+		case *Item:
+			writeTelephone(buf, stringValues(tel, "type"), stringValue(tel, "value"))
+		}
+	}
+
+	for _, v := range item.Properties["email"] {
+		switch email := v.(type) {
+		case string:
+			writeContentLine(buf, "EMAIL", escapeText(strings.TrimPrefix(cleanText(email), "mailto:")))
+		case *Item:
+			writeContentLine(buf, "EMAIL"+typeParam(stringValues(email, "type")), escapeText(strings.TrimPrefix(cleanText(stringValue(email, "value")), "mailto:")))
+		}
+	}
+
+	writeTextLines(buf, "TITLE", stringValues(item, "title"))
+	writeTextLines(buf, "ROLE", stringValues(item, "role"))
+
+	for _, v := range item.Properties["org"] {
+		switch org := v.(type) {
+		case string:
+			writeContentLine(buf, "ORG", structuredText(org))
+		case *Item:
+			writeContentLine(buf, "ORG", structuredText(stringValue(org, "organization-name"), stringValue(org, "organization-unit")))
+		}
+	}
+
+	writeTextLines(buf, "CATEGORIES", stringValues(item, "category"))
+	writeURILines(buf, "URL", item.Properties["url"])
+	writeTextLines(buf, "NOTE", stringValues(item, "note"))
+	writeTextLines(buf, "UID", stringValues(item, "uid"))
+}
+
+func writeTextLines(buf *bytes.Buffer, name string, values []string) {
+	for _, v := range values {
+		if v = cleanText(v); v != "" {
+			writeContentLine(buf, name, escapeText(v))
+		}
+	}
+}
+
+func writeURILines(buf *bytes.Buffer, name string, values valueList) {
+	for _, v := range values {
+		if u := strings.TrimSpace(urlValue(v)); u != "" {
+			writeContentLine(buf, name, u)
+		}
+	}
+}
+
+// writeTelephone writes a TEL property, using the uri value type for numbers
+// taken from tel: links.
+func writeTelephone(buf *bytes.Buffer, types []string, number string) {
+	number = cleanText(number)
+	if number == "" {
+		return
+	}
+	name := "TEL" + typeParam(types)
+	if strings.HasPrefix(number, "tel:") {
+		writeContentLine(buf, name+";VALUE=uri", number)
+		return
+	}
+	writeContentLine(buf, name, escapeText(number))
+}
+
+// typeParam returns a TYPE parameter listing the given types, or an empty
+// string if there are none. Each type is quoted separately if it needs to be,
+// so that the parameter holds a list of values rather than a single one.
+func typeParam(types []string) string {
+	values := make([]string, 0, len(types))
+	for _, t := range types {
+		if t = cleanText(t); t != "" {
+			values = append(values, paramValue(strings.ToLower(t)))
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return ";TYPE=" + strings.Join(values, ",")
+}
+
+// vCardDate converts an ISO 8601 extended date such as 1996-04-15 into the
+// basic format required by RFC 6350.
+func vCardDate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) == 10 && s[4] == '-' && s[7] == '-' {
+		return s[0:4] + s[5:7] + s[8:10]
+	}
+	return escapeText(s)
+}
+
+// structuredText escapes and joins the components of a structured property
+// value such as N or ADR.
+func structuredText(components ...string) string {
+	escaped := make([]string, len(components))
+	for i, c := range components {
+		escaped[i] = escapeText(cleanText(c))
+	}
+	return strings.Join(escaped, ";")
+}
+
+// escapeText escapes a text value as required by RFC 6350 and RFC 5545.
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case ',':
+			b.WriteString(`\,`)
+		case ';':
+			b.WriteString(`\;`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// paramValue quotes a property parameter value if it contains characters that
+// are not allowed in an unquoted value.
+func paramValue(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// writeContentLine writes a content line terminated by CRLF, folding it so
+// that no line exceeds 75 octets as required by RFC 6350 and RFC 5545.
+// Continuation lines begin with a space and multi-octet characters are never
+// split.
+func writeContentLine(buf *bytes.Buffer, name string, value string) {
+	line := name + ":" + value
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
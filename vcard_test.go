@@ -0,0 +1,140 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVCardPerson(t *testing.T) {
+	html := `<div itemscope itemtype="http://schema.org/Organization">
+	  <span itemprop="name">ACME, Inc.</span>
+	  <div itemprop="employee" itemscope itemtype="http://schema.org/Person">
+	    <span itemprop="givenName">Jane</span> <span itemprop="familyName">Doe</span>
+	    <span itemprop="jobTitle">Head of R&amp;D; Widgets</span>
+	    <a itemprop="email" href="mailto:jane@example.com">Email</a>
+	    <a itemprop="telephone" href="tel:+1-555-0100">Call</a>
+	    <img itemprop="image" src="jane.jpg">
+	    <div itemprop="address" itemscope itemtype="http://schema.org/PostalAddress">
+	      <span itemprop="streetAddress">1 Main St</span>
+	      <span itemprop="addressLocality">Springfield</span>
+	      <span itemprop="postalCode">12345</span>
+	    </div>
+	  </div>
+	</div>`
+
+	data := ParseData(html, t)
+
+	b, err := data.VCard()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := "BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"KIND:org\r\n" +
+		"FN:ACME\\, Inc.\r\n" +
+		"ORG:ACME\\, Inc.\r\n" +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"KIND:individual\r\n" +
+		"FN:Jane Doe\r\n" +
+		"N:Doe;Jane;;;\r\n" +
+		"TITLE:Head of R&D\\; Widgets\r\n" +
+		"PHOTO:http://example.com/jane.jpg\r\n" +
+		"EMAIL:jane@example.com\r\n" +
+		"TEL;VALUE=uri:tel:+1-555-0100\r\n" +
+		"ADR:;;1 Main St;Springfield;;12345;\r\n" +
+		"END:VCARD\r\n"
+
+	if string(b) != expected {
+		t.Errorf("Expecting:\n%s\nbut got:\n%s", expected, b)
+	}
+}
+
+func TestVCardHCard(t *testing.T) {
+	html := `<section itemscope itemtype="http://microformats.org/profile/hcard">
+	  <h1 itemprop="fn">Jack Bauer</h1>
+	  <span itemprop="n" itemscope>
+	    <span itemprop="given-name">Jack</span> <span itemprop="family-name">Bauer</span>
+	  </span>
+	  <span itemprop="tel" itemscope>
+	    <span itemprop="type">work</span> <span itemprop="value">+1 555 0101</span>
+	  </span>
+	  <span itemprop="org" itemscope>
+	    <span itemprop="organization-name">Counter-Terrorist Unit</span>
+	    <span itemprop="organization-unit">Field Operations</span>
+	  </span>
+	</section>`
+
+	item := ParseOneItem(html, t)
+
+	b, err := item.VCard()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	for _, line := range []string{"FN:Jack Bauer\r\n", "N:Bauer;Jack;;;\r\n", "TEL;TYPE=work:+1 555 0101\r\n", "ORG:Counter-Terrorist Unit;Field Operations\r\n"} {
+		if !strings.Contains(string(b), line) {
+			t.Errorf("Expecting line %q in:\n%s", line, b)
+		}
+	}
+}
+
+func TestVCardTypeList(t *testing.T) {
+	html := `<section itemscope itemtype="http://microformats.org/profile/hcard">
+	  <h1 itemprop="fn">Jack Bauer</h1>
+	  <span itemprop="tel" itemscope>
+	    <span itemprop="type">Work</span> <span itemprop="type">voice</span> <span itemprop="type">x:y</span>
+	    <span itemprop="value">+1 555 0101</span>
+	  </span>
+	  <span itemprop="email" itemscope>
+	    <span itemprop="type">work</span> <span itemprop="type">pref</span>
+	    <span itemprop="value">jack@example.com</span>
+	  </span>
+	</section>`
+
+	b, err := ParseOneItem(html, t).VCard()
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	for _, line := range []string{"TEL;TYPE=work,voice,\"x:y\":+1 555 0101\r\n", "EMAIL;TYPE=work,pref:jack@example.com\r\n"} {
+		if !strings.Contains(string(b), line) {
+			t.Errorf("Expecting line %q in:\n%s", line, b)
+		}
+	}
+}
+
+func TestVCardRejectsOtherTypes(t *testing.T) {
+	item := NewItem()
+	item.AddType("http://schema.org/Product")
+
+	if _, err := item.VCard(); err == nil {
+		t.Errorf("Expecting error for Product item")
+	}
+}
+
+func TestWriteContentLineFolds(t *testing.T) {
+	var buf bytes.Buffer
+	value := strings.Repeat("é", 60)
+
+	writeContentLine(&buf, "NOTE", value)
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expecting line of at most 75 octets but got %d", len(line))
+		}
+	}
+
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	if unfolded != "NOTE:"+value+"\r\n" {
+		t.Errorf("Expecting unfolded line to match original but got %q", unfolded)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrSkipItem is used as a return value from a WalkFunc to indicate that the
+// nested item being visited should not be descended into. It is not returned
+// as an error by Walk.
+var ErrSkipItem = errors.New("skip this item")
+
+// PathElem is one step in a Path: a property name and the position of the
+// value within that property's values. Top-level items of a microdata set
+// have an empty property name.
+type PathElem struct {
+	Property string
+	Index    int
+}
+
+// Path locates a value within an item or microdata set.
+type Path []PathElem
+
+// String formats the path as a sequence of property names and indexes,
+// such as "[0].offers[1].price[0]".
+func (p Path) String() string {
+	var b strings.Builder
+	for i, e := range p {
+		if i > 0 && e.Property != "" {
+			b.WriteByte('.')
+		}
+		b.WriteString(e.Property)
+		b.WriteByte('[')
+		b.WriteString(strconv.Itoa(e.Index))
+		b.WriteByte(']')
+	}
+	return b.String()
+}
+
+// Properties returns the property names of the path, omitting indexes and
+// top-level positions, such as "offers.price".
+func (p Path) Properties() string {
+	names := make([]string, 0, len(p))
+	for _, e := range p {
+		if e.Property != "" {
+			names = append(names, e.Property)
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// appendPath returns a copy of path extended by one step.
+func appendPath(path Path, property string, index int) Path {
+	p := make(Path, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, PathElem{Property: property, Index: index})
+}
+
+// Visit describes a value encountered during a walk.
+type Visit struct {
+	Path     Path        // location of the value
+	Parent   *Item       // item holding the value, nil for top-level items
+	Property string      // property name holding the value, empty for top-level items
+	Value    interface{} // a string or an *Item
+
+	// Shared is true when Value is an item that has already been visited
+	// through another path.
+	Shared bool
+
+	// Cycle is true when Value is an item that is also one of its own
+	// ancestors on Path. Walk never descends into such an item.
+	Cycle bool
+}
+
+// WalkFunc is the type of the function called for each value visited by Walk.
+// If the function returns ErrSkipItem when visiting a nested item then Walk does
+// not descend into that item. Any other non-nil error stops the walk and is
+// returned by Walk.
+type WalkFunc func(v Visit) error
+
+// Walk visits every property value of the item in depth-first order, calling
+// fn for each, including nested items and their values. Items that are reached
+// more than once are reported as shared and are descended into each time
+// unless they form a cycle.
+func (i *Item) Walk(fn WalkFunc) error {
+	w := &walker{fn: fn, seen: make(map[*Item]bool), ancestors: make(map[*Item]bool)}
+	w.seen[i] = true
+	return w.walkItem(nil, i)
+}
+
+// Walk visits every top-level item of the set and each of their property
+// values in depth-first order. See Item.Walk.
+func (m *Microdata) Walk(fn WalkFunc) error {
+	w := &walker{fn: fn, seen: make(map[*Item]bool), ancestors: make(map[*Item]bool)}
+	for idx, item := range m.Items {
+		path := Path{{Index: idx}}
+		if err := w.visit(Visit{Path: path, Value: item}); err != nil {
+			if err == ErrSkipItem {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+type walker struct {
+	fn        WalkFunc
+	seen      map[*Item]bool
+	ancestors map[*Item]bool
+}
+
+func (w *walker) visit(v Visit) error {
+	item, isItem := v.Value.(*Item)
+	if isItem {
+		v.Shared = w.seen[item]
+		v.Cycle = w.ancestors[item]
+		w.seen[item] = true
+	}
+
+	if err := w.fn(v); err != nil {
+		return err
+	}
+
+	if !isItem || v.Cycle {
+		return nil
+	}
+	return w.walkItem(v.Path, item)
+}
+
+func (w *walker) walkItem(path Path, item *Item) error {
+	w.ancestors[item] = true
+	defer delete(w.ancestors, item)
+
+	for _, name := range item.PropertyNames() {
+		for idx, value := range item.Properties[name] {
+			err := w.visit(Visit{Path: appendPath(path, name, idx), Parent: item, Property: name, Value: value})
+			if err == ErrSkipItem {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+  This is free and unencumbered software released into the public domain. For more
+  information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package microdata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkPaths(t *testing.T) {
+	html := `<div itemscope>
+			 <p>Name: <span itemprop="name">Amanda</span></p>
+			 <p>Band: <span itemprop="band" itemscope> <span itemprop="name">Jazz Band</span> (<span itemprop="size">12</span> players)</span></p>
+			</div>`
+
+	data := ParseData(html, t)
+
+	var paths []string
+	err := data.Walk(func(v Visit) error {
+		paths = append(paths, v.Path.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []string{"[0]", "[0].name[0]", "[0].band[0]", "[0].band[0].name[0]", "[0].band[0].size[0]"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expecting %v but got %v", expected, paths)
+	}
+}
+
+func TestWalkSkipItem(t *testing.T) {
+	band := NewItem()
+	band.AddString("name", "Jazz Band")
+
+	item := NewItem()
+	item.AddString("name", "Amanda")
+	item.AddItem("band", band)
+
+	var visited []string
+	err := item.Walk(func(v Visit) error {
+		visited = append(visited, v.Path.Properties())
+		if _, ok := v.Value.(*Item); ok {
+			return ErrSkipItem
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []string{"name", "band"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("Expecting %v but got %v", expected, visited)
+	}
+}
+
+func TestWalkSharedAndCyclic(t *testing.T) {
+	org := NewItem()
+	org.AddString("name", "ACME")
+
+	person := NewItem()
+	person.AddItem("worksFor", org)
+	person.AddItem("affiliation", org)
+	org.AddItem("employee", person)
+
+	data := NewMicrodata()
+	data.AddItem(person)
+
+	var shared, cycles []string
+	err := data.Walk(func(v Visit) error {
+		if v.Shared {
+			shared = append(shared, v.Path.String())
+		}
+		if v.Cycle {
+			cycles = append(cycles, v.Path.String())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expectedShared := []string{"[0].worksFor[0].employee[0]", "[0].affiliation[0]", "[0].affiliation[0].employee[0]"}
+	if !reflect.DeepEqual(shared, expectedShared) {
+		t.Errorf("Expecting shared %v but got %v", expectedShared, shared)
+	}
+
+	expectedCycles := []string{"[0].worksFor[0].employee[0]", "[0].affiliation[0].employee[0]"}
+	if !reflect.DeepEqual(cycles, expectedCycles) {
+		t.Errorf("Expecting cycles %v but got %v", expectedCycles, cycles)
+	}
+}